@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// achievements tracks the milestones of the session, each unlocked once.
+type achievements struct {
+	milestones []int        // Tree counts to reach, in increasing order
+	next       int          // Next milestone to reach
+	frames     int          // Number of sprite varieties
+	planted    map[int]bool // Varieties planted this session
+	allPlanted bool         // Whether every variety was planted
+}
+
+// newAchievements tracks the milestones from a forest of count trees, the ones it already
+// reached don't unlock again. A spritesheet of a single frame has no varieties to collect.
+func newAchievements(milestones []int, frameCount, count int) *achievements {
+	sorted := append([]int(nil), milestones...)
+	sort.Ints(sorted)
+	a := &achievements{milestones: sorted, frames: frameCount, planted: map[int]bool{}, allPlanted: frameCount < 2}
+	for a.next < len(a.milestones) && a.milestones[a.next] <= count {
+		a.next++
+	}
+	return a
+}
+
+// Planted records a tree of the given frame planted into a forest now holding count trees,
+// and returns the messages of the achievements it unlocked.
+func (a *achievements) Planted(frame, count int) []string {
+	var unlocked []string
+	for a.next < len(a.milestones) && a.milestones[a.next] <= count {
+		unlocked = append(unlocked, fmt.Sprintf("%d trees planted!", a.milestones[a.next]))
+		a.next++
+	}
+	a.planted[frame] = true
+	if !a.allPlanted && len(a.planted) == a.frames {
+		a.allPlanted = true
+		unlocked = append(unlocked, fmt.Sprintf("All %d tree varieties planted!", a.frames))
+	}
+	return unlocked
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAchievementsVarieties(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		plant  []int
+		want   []string // Messages of the last tree planted
+	}{
+		{"single frame", 1, []int{0, 0}, nil},
+		{"two frames", 2, []int{0, 0, 1}, []string{"All 2 tree varieties planted!"}},
+		{"once only", 2, []int{0, 1, 0, 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAchievements(nil, tt.frames, 0)
+			var got []string
+			for i, frame := range tt.plant {
+				got = a.Planted(frame, i+1)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unlocked %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAchievementsMilestones(t *testing.T) {
+	// Milestones the loaded forest already reached don't unlock again
+	a := newAchievements([]int{100, 10, 50}, 1, 20)
+	if got := a.Planted(0, 49); got != nil {
+		t.Fatalf("unlocked %q at 49 trees", got)
+	}
+	if got, want := a.Planted(0, 100), []string{"50 trees planted!", "100 trees planted!"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unlocked %q at 100 trees, want %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	benchmarkPerFrame = 100 // Trees planted each frame of a benchmark
+	benchmarkHold     = 5.0 // Seconds the whole forest is rendered once planted
+)
+
+// frameStats sums up the frames of a phase of a benchmark.
+type frameStats struct {
+	frames  int
+	seconds float64
+	slowest float64
+}
+
+// add records a frame that took dt seconds.
+func (s *frameStats) add(dt float64) {
+	s.frames++
+	s.seconds += dt
+	s.slowest = math.Max(s.slowest, dt)
+}
+
+// fps returns the average frame rate.
+func (s frameStats) fps() float64 {
+	if s.seconds == 0 {
+		return 0
+	}
+	return float64(s.frames) / s.seconds
+}
+
+// benchmark plants trees a batch every frame, then renders the whole forest for a while,
+// timing both phases.
+type benchmark struct {
+	trees            int        // Trees to plant
+	planted          int        // Trees planted so far
+	started          bool       // Whether the first frame was seen
+	plantedLastFrame bool       // Whether trees were planted in the previous frame
+	planting         frameStats // Frames planting trees
+	holding          frameStats // Frames rendering the whole forest
+}
+
+// newBenchmark returns a benchmark planting n trees.
+func newBenchmark(n int) *benchmark {
+	return &benchmark{trees: n}
+}
+
+// Frame records the previous frame, which took dt seconds, and returns how many trees to
+// plant in this one, or done once the benchmark is over.
+func (b *benchmark) Frame(dt float64) (plant int, done bool) {
+	// The first frame's dt covers the startup, not rendering
+	if !b.started {
+		b.started = true
+	} else if b.plantedLastFrame {
+		b.planting.add(dt)
+	} else {
+		b.holding.add(dt)
+	}
+	b.plantedLastFrame = b.planted < b.trees
+	if b.plantedLastFrame {
+		plant = b.trees - b.planted
+		if plant > benchmarkPerFrame {
+			plant = benchmarkPerFrame
+		}
+		b.planted += plant
+		return plant, false
+	}
+	return 0, b.holding.seconds >= benchmarkHold
+}
+
+// Report writes the summary of the benchmark, with what paced the frames (the frame rates are
+// only the true throughput with VSync off and no FPS cap).
+func (b *benchmark) Report(w io.Writer, pacing string) {
+	fmt.Fprintf(w, "Planted %d trees in %.2fs (%.0f trees/s, %d per frame)\n", b.planted, b.planting.seconds, float64(b.planted)/b.planting.seconds, benchmarkPerFrame)
+	fmt.Fprintf(w, "While planting: %.1f FPS, slowest frame %.1fms\n", b.planting.fps(), b.planting.slowest*1000)
+	fmt.Fprintf(w, "With %d trees: %.1f FPS, slowest frame %.1fms\n", b.planted, b.holding.fps(), b.holding.slowest*1000)
+	fmt.Fprintf(w, "Frame pacing: %s\n", pacing)
+}
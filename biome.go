@@ -0,0 +1,40 @@
+package main
+
+import "github.com/faiface/pixel"
+
+// Biome is a region of the world where random trees are picked from a subset of the frames.
+type Biome struct {
+	Name   string     `json:"name"`   // Name of the biome, for the config file reader
+	Rect   [4]float64 `json:"rect"`   // Region as min x, min y, max x, max y in world units
+	Frames []int      `json:"frames"` // Spritesheet frames planted in the region
+}
+
+// Bounds returns the region of the biome.
+func (b Biome) Bounds() pixel.Rect {
+	return pixel.R(b.Rect[0], b.Rect[1], b.Rect[2], b.Rect[3]).Norm()
+}
+
+// biomeFrames returns the frames random trees planted at pos are picked from, out of
+// frameCount frames. It returns nil, meaning every frame, when pos is in no biome or in
+// overlapping ones, or when the biome has no valid frame.
+func biomeFrames(biomes []Biome, pos pixel.Vec, frameCount int) []int {
+	var found *Biome
+	for i := range biomes {
+		if biomes[i].Bounds().Contains(pos) {
+			if found != nil {
+				return nil
+			}
+			found = &biomes[i]
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	var frames []int
+	for _, f := range found.Frames {
+		if f >= 0 && f < frameCount {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	maxBrushSize = 25            // Most trees planted by a single click
+	goldenAngle  = 2.39996322973 // Angle between successive points of a sunflower spiral, in radians
+)
+
+// brushRadius returns the radius of a brush planting size trees, each given about spread
+// world units of room. A brush of size 1 plants a single tree right at the cursor.
+func brushRadius(size int, spread float64) float64 {
+	if size <= 1 {
+		return 0
+	}
+	return spread * math.Sqrt(float64(size))
+}
+
+// brushOffsets returns size offsets scattered within radius of the brush center. They follow
+// a sunflower spiral, which spreads them evenly over the disc, jittered so the clusters don't
+// all look the same.
+func brushOffsets(rng *rand.Rand, size int, radius float64) []pixel.Vec {
+	if size <= 1 {
+		return []pixel.Vec{pixel.ZV}
+	}
+	offsets := make([]pixel.Vec, size)
+	for i := range offsets {
+		r := radius * math.Sqrt((float64(i)+0.5)/float64(size))
+		angle := float64(i)*goldenAngle + (rng.Float64()-0.5)*0.5
+		r *= 1 + (rng.Float64()-0.5)*0.3
+		offsets[i] = pixel.Unit(angle).Scaled(math.Min(r, radius))
+	}
+	return offsets
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// Camera is a view of the world, centered on Pos and drawn in a window of the given bounds.
+type Camera struct {
+	Pos          pixel.Vec  // World position at the center of the window
+	Velocity     pixel.Vec  // Current panning velocity from Steer, in world units per second
+	ZoomLevel    float64    // Current zoom level
+	Rotation     float64    // Rotation of the view around Pos, in radians
+	TargetZoom   float64    // Zoom level ZoomLevel eases toward, set by Zoom and ZoomAt
+	ZoomEasing   float64    // Rate at which the zoom eases toward its target, per second (0 is instant)
+	MinZoom      float64    // Minimum zoom level
+	MaxZoom      float64    // Maximum zoom level
+	Speed        float64    // Maximum panning speed in world units per second
+	Acceleration float64    // Panning acceleration in world units per second squared (0 is instant)
+	ZoomSpeed    float64    // Zoom factor per scroll step
+	ShakeScale   float64    // Factor of the intensity of every shake (0 disables shaking)
+	Bounds       pixel.Rect // Window bounds
+
+	zoomAnchor pixel.Vec // Window position kept in place while the zoom eases
+
+	shakeIntensity float64   // Offset of the current shake when it started, in window pixels
+	shakeDuration  float64   // Length of the current shake, in seconds
+	shakeLeft      float64   // Seconds left before the current shake stops
+	shakeOffset    pixel.Vec // Offset of the view this frame, in world units
+}
+
+// Matrix returns the matrix transforming world coordinates to window coordinates.
+// The view is offset while the camera shakes, Pos itself never moves.
+func (c *Camera) Matrix() pixel.Matrix {
+	pos := c.Pos.Add(c.shakeOffset)
+	return pixel.IM.Scaled(pos, c.ZoomLevel).Rotated(pos, c.Rotation).Moved(c.Bounds.Center().Sub(pos))
+}
+
+// Unproject converts a window position to a world position.
+func (c *Camera) Unproject(v pixel.Vec) pixel.Vec {
+	return c.Matrix().Unproject(v)
+}
+
+// View returns the world area visible in the window.
+func (c *Camera) View() pixel.Rect {
+	return visibleArea(c.Matrix(), c.Bounds)
+}
+
+// visibleArea returns the world area visible in a window through the camera matrix m: the
+// bounding box of the window corners, which the view may be rotated inside of.
+func visibleArea(m pixel.Matrix, window pixel.Rect) pixel.Rect {
+	corners := window.Vertices()
+	area := pixel.Rect{Min: m.Unproject(corners[0]), Max: m.Unproject(corners[0])}
+	for _, v := range corners[1:] {
+		p := m.Unproject(v)
+		area = pixel.R(math.Min(area.Min.X, p.X), math.Min(area.Min.Y, p.Y), math.Max(area.Max.X, p.X), math.Max(area.Max.Y, p.Y))
+	}
+	return area
+}
+
+// ScreenDir converts a direction on the screen (like up for the arrow key) to the world
+// direction it points to in the rotated view.
+func (c *Camera) ScreenDir(v pixel.Vec) pixel.Vec {
+	return v.Rotated(-c.Rotation)
+}
+
+// Pan moves the camera by a distance in world units.
+func (c *Camera) Pan(dx, dy float64) {
+	c.Pos = c.Pos.Add(pixel.V(dx, dy))
+}
+
+// Steer accelerates the camera toward full speed in the direction of input, or slows it down
+// to a stop when input is zero, and moves it for dt seconds. The input is normalized so that
+// diagonals are not faster.
+func (c *Camera) Steer(input pixel.Vec, dt float64) {
+	target := pixel.ZV
+	if input != pixel.ZV {
+		target = input.Unit().Scaled(c.Speed)
+	}
+	change := target.Sub(c.Velocity)
+	if step := c.Acceleration * dt; c.Acceleration > 0 && change.Len() > step {
+		change = change.Unit().Scaled(step)
+	}
+	c.Velocity = c.Velocity.Add(change)
+	c.Pan(c.Velocity.X*dt, c.Velocity.Y*dt)
+}
+
+// Zoom zooms in (positive amounts) or out by a number of scroll steps, around the window center.
+// The target zoom changes right away and the zoom level eases toward it with Ease.
+func (c *Camera) Zoom(amount float64) {
+	c.ZoomAt(amount, c.Bounds.Center())
+}
+
+// ZoomAt zooms like Zoom, but keeps the world point under the given window position in place.
+func (c *Camera) ZoomAt(amount float64, at pixel.Vec) {
+	if amount == 0 {
+		return
+	}
+	c.TargetZoom *= math.Pow(c.ZoomSpeed, amount)
+	// Clamp the zoom level to stay within the specified limits
+	c.TargetZoom = math.Max(c.MinZoom, math.Min(c.MaxZoom, c.TargetZoom))
+	c.zoomAnchor = at
+}
+
+// Ease moves the zoom level toward the target zoom for dt seconds, keeping the world point
+// under the zoom anchor in place.
+func (c *Camera) Ease(dt float64) {
+	if c.ZoomLevel == c.TargetZoom {
+		return
+	}
+	offset := c.zoomAnchor.Sub(c.Bounds.Center())
+	world := c.Pos.Add(offset.Scaled(1 / c.ZoomLevel))
+	if c.ZoomEasing <= 0 || math.Abs(c.TargetZoom-c.ZoomLevel) < 1e-3*c.TargetZoom {
+		c.ZoomLevel = c.TargetZoom
+	} else {
+		c.ZoomLevel += (c.TargetZoom - c.ZoomLevel) * (1 - math.Exp(-c.ZoomEasing*dt))
+	}
+	c.Pos = world.Sub(offset.Scaled(1 / c.ZoomLevel))
+}
+
+// Shake shakes the view for duration seconds, by a random offset of up to intensity window
+// pixels fading out to nothing. A weaker shake doesn't cut a stronger one short.
+func (c *Camera) Shake(intensity, duration float64) {
+	intensity *= c.ShakeScale
+	if intensity <= 0 || duration <= 0 || c.shakeLeft > 0 && c.shakeIntensity*c.shakeLeft/c.shakeDuration > intensity {
+		return
+	}
+	c.shakeIntensity, c.shakeDuration, c.shakeLeft = intensity, duration, duration
+}
+
+// UpdateShake picks the shake offset of the view for a frame dt seconds after the last one.
+func (c *Camera) UpdateShake(dt float64) {
+	c.shakeLeft = math.Max(0, c.shakeLeft-dt)
+	if c.shakeLeft == 0 {
+		c.shakeOffset = pixel.ZV
+		return
+	}
+	amount := c.shakeIntensity * c.shakeLeft / c.shakeDuration / c.ZoomLevel
+	c.shakeOffset = pixel.Unit(rand.Float64() * 2 * math.Pi).Scaled(amount * rand.Float64())
+}
+
+// Clamp keeps the visible area inside bounds. When the view is larger than the bounds, the
+// bounds are kept inside the view instead. The camera stops along an axis it was held back on,
+// so that panning away from the edge moves it right away.
+func (c *Camera) Clamp(bounds pixel.Rect) {
+	half := c.Bounds.Size().Scaled(0.5 / c.ZoomLevel)
+	clamp := func(v, min, max, half float64) float64 {
+		lo, hi := min+half, max-half
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		return math.Max(lo, math.Min(hi, v))
+	}
+	pos := pixel.V(
+		clamp(c.Pos.X, bounds.Min.X, bounds.Max.X, half.X),
+		clamp(c.Pos.Y, bounds.Min.Y, bounds.Max.Y, half.Y),
+	)
+	if pos.X != c.Pos.X {
+		c.Velocity.X = 0
+	}
+	if pos.Y != c.Pos.Y {
+		c.Velocity.Y = 0
+	}
+	c.Pos = pos
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func testCamera() *Camera {
+	return &Camera{
+		Pos:        pixel.V(100, 50),
+		ZoomLevel:  1,
+		TargetZoom: 1,
+		MinZoom:    0.1,
+		MaxZoom:    10,
+		ZoomSpeed:  1.2,
+		Bounds:     pixel.R(0, 0, 800, 600),
+	}
+}
+
+// near reports whether a and b are the same point, but for rounding.
+func near(a, b pixel.Vec) bool {
+	return a.To(b).Len() < 1e-6
+}
+
+// inside reports whether r is inside bounds, but for rounding.
+func inside(r, bounds pixel.Rect) bool {
+	return r.Min.X > bounds.Min.X-1e-6 && r.Min.Y > bounds.Min.Y-1e-6 && r.Max.X < bounds.Max.X+1e-6 && r.Max.Y < bounds.Max.Y+1e-6
+}
+
+func TestCameraRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoom     float64
+		rotation float64
+		shake    pixel.Vec
+	}{
+		{"identity", 1, 0, pixel.ZV},
+		{"zoomed in", 3.5, 0, pixel.ZV},
+		{"zoomed out", 0.25, 0, pixel.ZV},
+		{"rotated", 1, math.Pi / 3, pixel.ZV},
+		{"zoomed and rotated", 2, -2.5, pixel.ZV},
+		{"shaking", 1.5, 0.4, pixel.V(7, -3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCamera()
+			c.ZoomLevel, c.Rotation, c.shakeOffset = tt.zoom, tt.rotation, tt.shake
+			m := c.Matrix()
+			// The shaken camera position is at the center of the window
+			if got := m.Project(c.Pos.Add(tt.shake)); !near(got, c.Bounds.Center()) {
+				t.Fatalf("camera position projected to %v, want the window center %v", got, c.Bounds.Center())
+			}
+			for _, world := range []pixel.Vec{pixel.ZV, pixel.V(100, 50), pixel.V(-320, 1234.5), pixel.V(1e4, -1e4)} {
+				if got := c.Unproject(m.Project(world)); !near(got, world) {
+					t.Errorf("%v projected and unprojected to %v", world, got)
+				}
+			}
+			for _, window := range c.Bounds.Vertices() {
+				if got := m.Project(c.Unproject(window)); !near(got, window) {
+					t.Errorf("%v unprojected and projected to %v", window, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCameraZoomAtKeepsAnchor(t *testing.T) {
+	c := testCamera()
+	c.ZoomEasing = 8
+	at := pixel.V(650, 120)
+	world := c.Unproject(at)
+	c.ZoomAt(3, at)
+	for i := 0; i < 200; i++ {
+		c.Ease(1.0 / 60)
+		if got := c.Matrix().Project(world); !near(got, at) {
+			t.Fatalf("frame %d: the anchored point moved to %v", i, got)
+		}
+	}
+	if c.ZoomLevel != c.TargetZoom {
+		t.Fatalf("zoom level %v didn't reach the target %v", c.ZoomLevel, c.TargetZoom)
+	}
+}
+
+func TestCameraZoomLimits(t *testing.T) {
+	c := testCamera()
+	c.Zoom(100)
+	if c.TargetZoom != c.MaxZoom {
+		t.Fatalf("zoomed in to %v, want the maximum %v", c.TargetZoom, c.MaxZoom)
+	}
+	c.Zoom(-1000)
+	if c.TargetZoom != c.MinZoom {
+		t.Fatalf("zoomed out to %v, want the minimum %v", c.TargetZoom, c.MinZoom)
+	}
+}
+
+func TestCameraClamp(t *testing.T) {
+	bounds := pixel.R(-1000, -1000, 1000, 1000)
+	tests := []struct {
+		name string
+		pos  pixel.Vec
+		zoom float64
+		want pixel.Vec
+	}{
+		{"inside", pixel.V(100, -200), 1, pixel.V(100, -200)},
+		{"past the left edge", pixel.V(-900, 0), 1, pixel.V(-600, 0)},
+		{"past the top right corner", pixel.V(5000, 5000), 1, pixel.V(600, 700)},
+		{"zoomed in near the edge", pixel.V(-900, 900), 4, pixel.V(-900, 900)},
+		{"zoomed in past the edge", pixel.V(-999, 999), 4, pixel.V(-900, 925)},
+		// Larger than the bounds, the view keeps them inside itself
+		{"zoomed out", pixel.V(0, 0), 0.25, pixel.V(0, 0)},
+		{"zoomed out off center", pixel.V(1000, -800), 0.25, pixel.V(600, -200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCamera()
+			c.Pos, c.ZoomLevel = tt.pos, tt.zoom
+			c.Clamp(bounds)
+			if !near(c.Pos, tt.want) {
+				t.Fatalf("clamped to %v, want %v", c.Pos, tt.want)
+			}
+			view := c.View()
+			if tt.zoom >= 1 && !inside(view, bounds) {
+				t.Fatalf("view %v not inside the bounds", view)
+			}
+			if tt.zoom < 1 && !inside(bounds, view) {
+				t.Fatalf("bounds not inside the view %v", view)
+			}
+		})
+	}
+}
+
+func TestCameraClampStopsAtTheEdge(t *testing.T) {
+	bounds := pixel.R(-1000, -1000, 1000, 1000)
+	c := testCamera()
+	c.Speed, c.Acceleration = 500, 1000
+	// Steer right and up into the corner of the bounds for a while
+	for i := 0; i < 600; i++ {
+		c.Steer(pixel.V(1, 1), 1.0/60)
+		c.Clamp(bounds)
+	}
+	if !near(c.Pos, pixel.V(600, 700)) {
+		t.Fatalf("camera at %v, want it stopped at the corner", c.Pos)
+	}
+	if c.Velocity != pixel.ZV {
+		t.Fatalf("velocity %v kept into the edge", c.Velocity)
+	}
+
+	// Steering back moves away from the edge from the first frame
+	c.Steer(pixel.V(-1, 0), 1.0/60)
+	c.Clamp(bounds)
+	if c.Pos.X >= 600 || c.Velocity.X >= 0 {
+		t.Fatalf("steering left from the edge: at %v, velocity %v", c.Pos, c.Velocity)
+	}
+	if c.Pos.Y != 700 || c.Velocity.Y != 0 {
+		t.Fatalf("steering left moved up or down: at %v, velocity %v", c.Pos, c.Velocity)
+	}
+}
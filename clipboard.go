@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+// copyTrees returns the trees at the given indices in planting order, positioned relative to
+// their centroid so they can be pasted anywhere with pasteTrees.
+func copyTrees(trees []PlantedTree, indices map[int]bool) []PlantedTree {
+	if len(indices) == 0 {
+		return nil
+	}
+	order := sortedIndices(indices)
+	var centroid pixel.Vec
+	for _, i := range order {
+		centroid = centroid.Add(trees[i].Pos())
+	}
+	centroid = centroid.Scaled(1 / float64(len(order)))
+	group := make([]PlantedTree, len(order))
+	for j, i := range order {
+		group[j] = trees[i]
+		group[j].X, group[j].Y = trees[i].X-centroid.X, trees[i].Y-centroid.Y
+	}
+	return group
+}
+
+// pasteTrees returns the copied group centered at pos, planted at the given time.
+func pasteTrees(group []PlantedTree, pos pixel.Vec, planted time.Time) []PlantedTree {
+	pasted := make([]PlantedTree, len(group))
+	for i, t := range group {
+		t.X, t.Y, t.Planted = t.X+pos.X, t.Y+pos.Y, planted
+		pasted[i] = t
+	}
+	return pasted
+}
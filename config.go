@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Config holds the game settings that can be tuned from config.json.
+type Config struct {
+	CamSpeed         float64   `json:"cam_speed"`          // Maximum camera speed
+	CamAcceleration  float64   `json:"cam_acceleration"`   // Camera acceleration and deceleration (0 is instant)
+	MinZoom          float64   `json:"min_zoom"`           // Minimum zoom level
+	MaxZoom          float64   `json:"max_zoom"`           // Maximum zoom level
+	CamZoom          float64   `json:"cam_zoom"`           // Zoom level at startup, between min_zoom and max_zoom
+	CamPos           []float64 `json:"cam_pos"`            // World position [x, y] the camera starts at (empty for the center of the window)
+	CamZoomSpeed     float64   `json:"cam_zoom_speed"`     // Camera zoom speed
+	CamZoomEasing    float64   `json:"cam_zoom_easing"`    // How fast the zoom eases toward its target, per second (0 is instant)
+	CamShake         float64   `json:"cam_shake"`          // Strength of the camera shakes, like when filling a rectangle (0 disables)
+	InitialFontScale float64   `json:"initial_font_scale"` // Initial font scale
+	GridSize         float64   `json:"grid_size"`          // Cell size in world units for grid-snap planting
+	RotationJitter   float64   `json:"rotation_jitter"`    // Maximum random rotation of planted trees, in radians
+	RandomFlip       bool      `json:"random_flip"`        // Mirror planted trees at random while the random rotation is on, instead of following the flip toggle
+	TreeScale        float64   `json:"tree_scale"`         // Draw scale of planted trees, the size of their sprites in world units per pixel
+	MinTreeScale     float64   `json:"min_tree_scale"`     // Smallest random scale of planted trees at the default tree_scale of 4 (scaled along with it)
+	MaxTreeScale     float64   `json:"max_tree_scale"`     // Largest random scale of planted trees at the default tree_scale of 4 (scaled along with it)
+	MinSpacing       float64   `json:"min_spacing"`        // Minimum distance between trees in world units (0 disables)
+	TourSpeed        float64   `json:"tour_speed"`         // Average camera speed of the tour mode, in world units per second
+	Milestones       []int     `json:"milestones"`         // Tree counts congratulated when reached
+	SeedEconomy      bool      `json:"seed_economy"`       // Spend seeds to plant trees, regenerating over time
+	SeedStart        float64   `json:"seed_start"`         // Seeds at startup, and the most that regenerate
+	SeedRegen        float64   `json:"seed_regen"`         // Seeds regained per second
+	SeedCost         float64   `json:"seed_cost"`          // Seeds spent per tree planted
+	ClearTimeout     float64   `json:"clear_timeout"`      // Seconds to confirm clearing the forest
+	Autosave         float64   `json:"autosave"`           // Seconds between saves of the forest to autosave.json (0 disables)
+	MaxTrees         int       `json:"max_trees"`          // Most trees that can be planted (0 is unlimited)
+	PlantCooldown    float64   `json:"plant_cooldown"`     // Minimum seconds between two clicks planting a tree (0 disables)
+	PaintSpacing     float64   `json:"paint_spacing"`      // Distance between the trees painted by dragging, in world units (0 disables)
+	FillDensity      float64   `json:"fill_density"`       // Trees scattered per 100x100 world units by the rectangle fill
+	BrushSpread      float64   `json:"brush_spread"`       // Room given to each tree of a brush larger than one tree, in world units
+	Biomes           []Biome   `json:"biomes"`             // Regions planting random trees from their own frames
+	DayLength        float64   `json:"day_length"`         // Length of a full day/night cycle in seconds (0 disables)
+	SwayAmplitude    float64   `json:"sway_amplitude"`     // Maximum wind rotation of the trees, in radians
+	SwaySpeed        float64   `json:"sway_speed"`         // Speed of the wind sway, in radians per second
+	GrowthDuration   float64   `json:"growth_duration"`    // Seconds for a sapling to reach full size (0 disables)
+	SaplingFrame     int       `json:"sapling_frame"`      // Frame drawn during the first half of growth (-1 keeps the tree's own)
+	PopDuration      float64   `json:"pop_duration"`       // Seconds for a planted tree to settle from its pop (0 disables)
+	BackgroundColor  string    `json:"background_color"`   // Background color, like "#4F8227" (falls back to it with a warning when invalid)
+	GradientTop      string    `json:"gradient_top"`       // Background color at the top of the window, like "#87CEEB" (empty for the flat grass color)
+	GradientBottom   string    `json:"gradient_bottom"`    // Background color at the bottom of the window, fading from gradient_top
+	DepthSort        bool      `json:"depth_sort"`         // Draw lower trees in front of the ones above them instead of in planting order
+	Supersample      float64   `json:"supersample"`        // Resolution factor the world is drawn at before downsampling, for anti-aliasing (1 disables)
+	Smooth           bool      `json:"smooth"`             // Smooth (linear) sprite sampling instead of crisp pixel art
+	ShadowOpacity    float64   `json:"shadow_opacity"`     // Opacity of the tree shadows at noon, from 0 (no shadows) to 1
+	ShadowOffset     float64   `json:"shadow_offset"`      // Distance of the shadows below the trunks, in spritesheet pixels
+	LODZoom          float64   `json:"lod_zoom"`           // Zoom level below which trees are drawn as dots (0 disables)
+	LODDotSize       float64   `json:"lod_dot_size"`       // Size of the dots in screen pixels
+	LabelZoom        float64   `json:"label_zoom"`         // Zoom level below which the tree labels are hidden
+	HeatmapCell      float64   `json:"heatmap_cell"`       // Cell size of the tree density heatmap, in world units
+	MinimapSize      float64   `json:"minimap_size"`       // Width of the minimap in pixels
+	MinimapCorner    string    `json:"minimap_corner"`     // Window corner of the minimap: top-left, top-right, bottom-left or bottom-right
+	VSync            bool      `json:"vsync"`              // Synchronize the frames with the monitor refresh rate
+	FPSCap           float64   `json:"fps_cap"`            // Frame rate to cap the game at by sleeping out the rest of each frame (0 for no cap)
+	FPSSamples       int       `json:"fps_samples"`        // Number of frames the FPS is averaged over
+	GraphSamples     int       `json:"graph_samples"`      // Number of frames shown by the frame time graph
+	GraphWidth       float64   `json:"graph_width"`        // Width of the frame time graph in pixels
+	GraphHeight      float64   `json:"graph_height"`       // Height of the frame time graph in pixels
+	ReplaySpeed      float64   `json:"replay_speed"`       // Speed factor of -replay (2 replays twice as fast)
+	VignetteStrength float64   `json:"vignette_strength"`  // Opacity of the vignette in the corners of the window, from 0 to 1
+	Weather          string    `json:"weather"`            // Weather at startup: clear, rain or snow
+	WeatherDensity   float64   `json:"weather_density"`    // Raindrops or snowflakes per 100x100 pixels of the window
+	WeatherSpeed     float64   `json:"weather_speed"`      // Fall speed of the rain in pixels per second, snow falls slower
+	Volume           float64   `json:"volume"`             // Sound volume, from 0 (silent) to 1
+	ShowHelp         bool      `json:"show_help"`          // Show the tutorial text at startup (H toggles it)
+	PlantPitch       float64   `json:"plant_pitch"`        // Random change of the plant sound's pitch, 0.1 for up to 10% lower or higher (0 disables)
+	MapSize          int       `json:"map_size"`           // Longest side in pixels of the forest map saved with F10
+}
+
+// defaultConfig returns the settings used when no config file overrides them.
+func defaultConfig() Config {
+	return Config{
+		CamSpeed:         500.0,
+		CamAcceleration:  2000,
+		MinZoom:          0.2,
+		MaxZoom:          2.0,
+		CamZoom:          1.0,
+		CamZoomSpeed:     1.2,
+		CamZoomEasing:    12,
+		CamShake:         1,
+		InitialFontScale: 2.0,
+		GridSize:         64,
+		RotationJitter:   0.15,
+		TreeScale:        defaultTreeScale,
+		MinTreeScale:     3.0,
+		MaxTreeScale:     5.0,
+		TourSpeed:        150,
+		Milestones:       []int{10, 100, 1000},
+		SeedStart:        20,
+		SeedRegen:        0.5,
+		SeedCost:         1,
+		ClearTimeout:     2.0,
+		PlantCooldown:    0.05,
+		PaintSpacing:     48,
+		BrushSpread:      40,
+		FillDensity:      2,
+		DayLength:        120,
+		SwayAmplitude:    0.05,
+		SwaySpeed:        2.0,
+		GrowthDuration:   3.0,
+		SaplingFrame:     -1,
+		PopDuration:      0.2,
+		DepthSort:        true,
+		Supersample:      1,
+		BackgroundColor:  "#4F8227",
+		ShadowOpacity:    0.3,
+		ShadowOffset:     1,
+		LODZoom:          0.35,
+		LODDotSize:       3,
+		LabelZoom:        0.5,
+		HeatmapCell:      200,
+		MinimapSize:      200,
+		MinimapCorner:    "bottom-right",
+		VSync:            true,
+		FPSSamples:       60,
+		GraphSamples:     120,
+		GraphWidth:       240,
+		GraphHeight:      80,
+		ReplaySpeed:      10,
+		VignetteStrength: 0.6,
+		Weather:          "clear",
+		WeatherDensity:   0.5,
+		WeatherSpeed:     800,
+		Volume:           0.8,
+		ShowHelp:         true,
+		PlantPitch:       0.1,
+		MapSize:          4096,
+	}
+}
+
+// loadConfig reads settings from a JSON file. Fields absent from the file, or the whole
+// file being missing, keep their default values.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return cfg, fmt.Errorf("%s: invalid JSON at byte %d: %v", path, syntaxErr.Offset, err)
+		}
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.CamAcceleration < 0 {
+		return cfg, fmt.Errorf("%s: cam_acceleration must not be negative", path)
+	}
+	if cfg.MinZoom <= 0 || cfg.MinZoom >= cfg.MaxZoom {
+		return cfg, fmt.Errorf("%s: min_zoom must be positive and below max_zoom", path)
+	}
+	if cfg.CamZoomSpeed <= 1 {
+		return cfg, fmt.Errorf("%s: cam_zoom_speed must be greater than 1", path)
+	}
+	if cfg.CamZoom < cfg.MinZoom || cfg.CamZoom > cfg.MaxZoom {
+		return cfg, fmt.Errorf("%s: cam_zoom must be between min_zoom and max_zoom", path)
+	}
+	if len(cfg.CamPos) != 0 && len(cfg.CamPos) != 2 {
+		return cfg, fmt.Errorf("%s: cam_pos must be [x, y]", path)
+	}
+	if cfg.CamShake < 0 {
+		return cfg, fmt.Errorf("%s: cam_shake must not be negative", path)
+	}
+	if cfg.GridSize <= 0 {
+		return cfg, fmt.Errorf("%s: grid_size must be positive", path)
+	}
+	if cfg.TreeScale <= 0 {
+		return cfg, fmt.Errorf("%s: tree_scale must be positive", path)
+	}
+	if cfg.MinTreeScale <= 0 || cfg.MinTreeScale > cfg.MaxTreeScale {
+		return cfg, fmt.Errorf("%s: tree scales must be positive with min_tree_scale <= max_tree_scale", path)
+	}
+	if cfg.MaxTrees < 0 {
+		return cfg, fmt.Errorf("%s: max_trees must not be negative", path)
+	}
+	if cfg.ClearTimeout < 0 {
+		return cfg, fmt.Errorf("%s: clear_timeout must not be negative", path)
+	}
+	if cfg.BrushSpread <= 0 {
+		return cfg, fmt.Errorf("%s: brush_spread must be positive", path)
+	}
+	if cfg.FillDensity < 0 {
+		return cfg, fmt.Errorf("%s: fill_density must not be negative", path)
+	}
+	if cfg.PaintSpacing < 0 {
+		return cfg, fmt.Errorf("%s: paint_spacing must not be negative", path)
+	}
+	if cfg.SeedStart < 0 || cfg.SeedRegen < 0 || cfg.SeedCost < 0 {
+		return cfg, fmt.Errorf("%s: seed_start, seed_regen and seed_cost must not be negative", path)
+	}
+	for _, m := range cfg.Milestones {
+		if m <= 0 {
+			return cfg, fmt.Errorf("%s: milestones must be positive", path)
+		}
+	}
+	for _, b := range cfg.Biomes {
+		if len(b.Frames) == 0 {
+			return cfg, fmt.Errorf("%s: biome %q has no frames", path, b.Name)
+		}
+	}
+	if cfg.SaplingFrame < -1 {
+		return cfg, fmt.Errorf("%s: sapling_frame must be a frame, or -1 for none", path)
+	}
+	if cfg.Autosave < 0 {
+		return cfg, fmt.Errorf("%s: autosave must not be negative", path)
+	}
+	if cfg.HeatmapCell <= 0 {
+		return cfg, fmt.Errorf("%s: heatmap_cell must be positive", path)
+	}
+	if cfg.FPSCap < 0 {
+		return cfg, fmt.Errorf("%s: fps_cap must not be negative", path)
+	}
+	if cfg.FPSSamples <= 0 {
+		return cfg, fmt.Errorf("%s: fps_samples must be positive", path)
+	}
+	if (cfg.GradientTop == "") != (cfg.GradientBottom == "") {
+		return cfg, fmt.Errorf("%s: gradient_top and gradient_bottom must be set together", path)
+	}
+	for _, c := range []string{cfg.GradientTop, cfg.GradientBottom} {
+		if _, err := parseColor(c); c != "" && err != nil {
+			return cfg, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if cfg.GraphSamples < 2 || cfg.GraphWidth <= 0 || cfg.GraphHeight <= 0 {
+		return cfg, fmt.Errorf("%s: graph_samples must be at least 2 and the graph size positive", path)
+	}
+	if cfg.Supersample < 1 {
+		return cfg, fmt.Errorf("%s: supersample must be at least 1", path)
+	}
+	if cfg.ShadowOpacity < 0 || cfg.ShadowOpacity > 1 {
+		return cfg, fmt.Errorf("%s: shadow_opacity must be between 0 and 1", path)
+	}
+	if cfg.LabelZoom < 0 {
+		return cfg, fmt.Errorf("%s: label_zoom must not be negative", path)
+	}
+	if cfg.LODDotSize <= 0 {
+		return cfg, fmt.Errorf("%s: lod_dot_size must be positive", path)
+	}
+	if cfg.VignetteStrength < 0 || cfg.VignetteStrength > 1 {
+		return cfg, fmt.Errorf("%s: vignette_strength must be between 0 and 1", path)
+	}
+	if _, ok := weatherKind(cfg.Weather); !ok {
+		return cfg, fmt.Errorf("%s: unknown weather %q", path, cfg.Weather)
+	}
+	if cfg.WeatherDensity < 0 || cfg.WeatherSpeed <= 0 {
+		return cfg, fmt.Errorf("%s: weather_density must not be negative and weather_speed must be positive", path)
+	}
+	if cfg.Volume < 0 || cfg.Volume > 1 {
+		return cfg, fmt.Errorf("%s: volume must be between 0 and 1", path)
+	}
+	if cfg.MapSize < 1 || cfg.MapSize > maxMapSize {
+		return cfg, fmt.Errorf("%s: map_size must be between 1 and %d", path, maxMapSize)
+	}
+	if cfg.PlantPitch < 0 || cfg.PlantPitch >= 1 {
+		return cfg, fmt.Errorf("%s: plant_pitch must be at least 0 and below 1", path)
+	}
+	if cfg.TourSpeed <= 0 {
+		return cfg, fmt.Errorf("%s: tour_speed must be positive", path)
+	}
+	if cfg.ReplaySpeed <= 0 {
+		return cfg, fmt.Errorf("%s: replay_speed must be positive", path)
+	}
+	switch cfg.MinimapCorner {
+	case "top-left", "top-right", "bottom-left", "bottom-right":
+	default:
+		return cfg, fmt.Errorf("%s: unknown minimap_corner %q", path, cfg.MinimapCorner)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes settings to a JSON file that loadConfig reads back.
+func saveConfig(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// seconds converts a duration in seconds, as used in the config file, to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigLimits(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string // Part of the error, empty for none
+	}{
+		{`{}`, ""},
+		{`{"clear_timeout": 0}`, ""},
+		{`{"clear_timeout": -1}`, "clear_timeout must not be negative"},
+		{`{"paint_spacing": 0}`, ""},
+		{`{"paint_spacing": -5}`, "paint_spacing must not be negative"},
+		{`{"sapling_frame": -1}`, ""},
+		{`{"sapling_frame": 2}`, ""},
+		{`{"sapling_frame": -2}`, "sapling_frame must be a frame, or -1 for none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadConfig(path)
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
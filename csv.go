@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// csvHeader lists the columns of a forest CSV file.
+var csvHeader = []string{"x", "y", "frame", "scale", "rotation", "flip"}
+
+// exportCSV writes the planted trees to a CSV file, one row per tree after a header row.
+func exportCSV(path string, trees []PlantedTree) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	w.Write(csvHeader)
+	for _, t := range trees {
+		flip := 0
+		if t.Flip {
+			flip = 1
+		}
+		w.Write([]string{
+			strconv.FormatFloat(t.X, 'f', -1, 64),
+			strconv.FormatFloat(t.Y, 'f', -1, 64),
+			strconv.Itoa(t.Frame),
+			strconv.FormatFloat(t.Scale, 'f', -1, 64),
+			strconv.FormatFloat(t.Rotation, 'f', -1, 64),
+			strconv.Itoa(flip),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// importCSV reads trees from a CSV file. The columns are looked up by name from a header row
+// (x, y, frame, scale, rotation, flip); without a header they are taken in that order. Only x and
+// y are required: a missing frame defaults to 0, missing scale or rotation get the defaults, and
+// trees are only mirrored with a non-zero flip. Frame indices still need to be checked against
+// the spritesheet with repairForest.
+func importCSV(path string) ([]PlantedTree, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	columns := map[string]int{}
+	for i, name := range csvHeader {
+		columns[name] = i
+	}
+	var trees []PlantedTree
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// A first row that doesn't start with a number is the header
+		if line == 1 {
+			if _, err := strconv.ParseFloat(row[0], 64); err != nil {
+				columns = map[string]int{}
+				for i, name := range row {
+					columns[strings.ToLower(strings.TrimSpace(name))] = i
+				}
+				if _, ok := columns["x"]; !ok {
+					return nil, fmt.Errorf("%s: header has no x column", path)
+				}
+				if _, ok := columns["y"]; !ok {
+					return nil, fmt.Errorf("%s: header has no y column", path)
+				}
+				continue
+			}
+		}
+		field := func(name string, def float64) (float64, error) {
+			i, ok := columns[name]
+			if !ok || i >= len(row) || strings.TrimSpace(row[i]) == "" {
+				if name == "x" || name == "y" {
+					return 0, fmt.Errorf("%s:%d: missing %s", path, line, name)
+				}
+				return def, nil
+			}
+			// NaN and infinities parse, but can't be drawn or saved
+			v, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return 0, fmt.Errorf("%s:%d: invalid %s %q", path, line, name, row[i])
+			}
+			return v, nil
+		}
+		var t PlantedTree
+		var frame, flip float64
+		for _, f := range []struct {
+			name string
+			def  float64
+			dst  *float64
+		}{
+			{"x", 0, &t.X},
+			{"y", 0, &t.Y},
+			{"frame", 0, &frame},
+			{"scale", defaultTreeScale, &t.Scale},
+			{"rotation", 0, &t.Rotation},
+			{"flip", 0, &flip},
+		} {
+			if *f.dst, err = field(f.name, f.def); err != nil {
+				return nil, err
+			}
+		}
+		t.Frame, t.Flip = int(frame), flip != 0
+		trees = append(trees, t)
+	}
+	return trees, nil
+}
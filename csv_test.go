@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []PlantedTree
+		err  string // Part of the error, empty for none
+	}{
+		{"header", "x,y,frame,scale,rotation,flip\n1,2,3,5,0.5,1\n", []PlantedTree{{X: 1, Y: 2, Frame: 3, Scale: 5, Rotation: 0.5, Flip: true}}, ""},
+		{"no header", "1,2\n-3.5,4,1\n", []PlantedTree{{X: 1, Y: 2, Scale: defaultTreeScale}, {X: -3.5, Y: 4, Frame: 1, Scale: defaultTreeScale}}, ""},
+		{"columns by name", "Y, X, Flip\n2,1,0\n", []PlantedTree{{X: 1, Y: 2, Scale: defaultTreeScale}}, ""},
+		{"empty", "", nil, ""},
+		{"no y column", "x,frame\n1,2\n", nil, "header has no y column"},
+		{"missing y", "1,2\n3\n", nil, ":2: missing y"},
+		{"not a number", "1,2\n3,four\n", nil, `:2: invalid y "four"`},
+		{"NaN x", "NaN,0\n", nil, `:1: invalid x "NaN"`},
+		{"infinite y", "x,y\n1,2\n0,-Inf\n", nil, `:3: invalid y "-Inf"`},
+		{"infinite scale", "x,y,scale\n1,2,+Inf\n", nil, `:2: invalid scale "+Inf"`},
+		{"NaN rotation", "x,y,rotation\n1,2,nan\n", nil, `:2: invalid rotation "nan"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "forest.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			trees, err := importCSV(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(trees, tt.want) {
+				t.Fatalf("imported %+v, want %+v", trees, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportImportCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forest.csv")
+	trees := []PlantedTree{{X: 1.25, Y: -2, Frame: 2, Scale: 3, Rotation: -0.1, Flip: true}, {X: 0, Y: 0, Scale: 4}}
+	if err := exportCSV(path, trees); err != nil {
+		t.Fatal(err)
+	}
+	got, err := importCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, trees) {
+		t.Fatalf("imported %+v, want %+v", got, trees)
+	}
+}
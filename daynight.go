@@ -0,0 +1,23 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+var (
+	nightColor = pixel.RGB(0x12/255.0, 0x2B/255.0, 0x33/255.0) // Dark blue-green background at midnight
+	nightTint  = pixel.RGB(0.35, 0.4, 0.55)                    // Color mask applied to the trees at midnight
+)
+
+// daylight returns the brightness of the scene for a time of day in [0, 1):
+// 1 at noon (0) and 0 at midnight (0.5).
+func daylight(timeOfDay float64) float64 {
+	return 0.5 + 0.5*math.Cos(2*math.Pi*timeOfDay)
+}
+
+// lerpColor blends from color a to color b, t going from 0 to 1.
+func lerpColor(a, b pixel.RGBA, t float64) pixel.RGBA {
+	return a.Scaled(1 - t).Add(b.Scaled(t))
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+// PlantedTree holds everything needed to redraw a single planted tree.
+type PlantedTree struct {
+	X        float64 `json:"x"`               // World position X
+	Y        float64 `json:"y"`               // World position Y
+	Frame    int     `json:"frame"`           // Index into the spritesheet frames
+	Scale    float64 `json:"scale"`           // Draw scale
+	Rotation float64 `json:"rotation"`        // Rotation in radians
+	Flip     bool    `json:"flip,omitempty"`  // Mirrored horizontally
+	Label    string  `json:"label,omitempty"` // Text shown above the tree (empty for none)
+
+	Planted time.Time `json:"-"` // When the tree was planted this session (zero for loaded trees)
+}
+
+// defaultTreeScale is the draw scale used when a tree doesn't specify one.
+const defaultTreeScale = 4
+
+// Pos returns the world position of the tree.
+func (t PlantedTree) Pos() pixel.Vec {
+	return pixel.V(t.X, t.Y)
+}
+
+// Matrix returns the transformation used to draw the tree.
+func (t PlantedTree) Matrix() pixel.Matrix {
+	scale := pixel.V(t.Scale, t.Scale)
+	if t.Flip {
+		scale.X = -scale.X
+	}
+	return pixel.IM.ScaledXY(pixel.ZV, scale).Rotated(pixel.ZV, t.Rotation).Moved(t.Pos())
+}
+
+// Forest holds the planted trees, the source of truth for what the tree batch shows.
+type Forest struct {
+	Trees []PlantedTree // Planted trees, in planting order
+
+	bounds      pixel.Rect    // Area covered by the spatial index
+	index       *quadTree     // Spatial index of the trees, by position in Trees
+	spritesheet pixel.Picture // Spritesheet the trees are cut from
+	frames      []pixel.Rect  // Frames of the spritesheet
+
+	listeners []func(ForestEvent) // Called after every change, see Subscribe
+
+	drawn int  // Trees[:drawn] are in the batch as of the last Rebuild or Sync
+	dirty bool // Trees were removed or moved, or drawn animated, since the batch was last rebuilt
+
+	depthSort bool          // Draw the trees from back (top of the world) to front instead of in planting order
+	sorted    []PlantedTree // Trees in depth order, nil when out of date
+}
+
+// ForestEvent describes a single change of the forest.
+type ForestEvent struct {
+	Type string       `json:"type"`           // "plant", "remove", "move", "label" or "clear"
+	Tree *PlantedTree `json:"tree,omitempty"` // Tree planted, removed, moved or labeled (nil for "clear")
+	From *PlantedTree `json:"from,omitempty"` // Tree before it was moved or labeled (only for "move" and "label")
+
+	Shifted bool `json:"-"` // The tree was put back among the others, so the indices of the trees after it changed
+}
+
+// Kinds of forest events.
+const (
+	EventPlant  = "plant"
+	EventRemove = "remove"
+	EventMove   = "move"
+	EventLabel  = "label"
+	EventClear  = "clear"
+)
+
+// NewForest returns an empty forest indexed over bounds, drawn from the frames of the spritesheet.
+func NewForest(bounds pixel.Rect, spritesheet pixel.Picture, frames []pixel.Rect) *Forest {
+	return &Forest{
+		bounds:      bounds,
+		index:       newQuadTree(bounds),
+		spritesheet: spritesheet,
+		frames:      frames,
+	}
+}
+
+// Subscribe registers fn to be called after every change of the forest. It runs on the
+// goroutine changing the forest (the game loop), so it must not block.
+func (f *Forest) Subscribe(fn func(ForestEvent)) {
+	f.listeners = append(f.listeners, fn)
+}
+
+// emit notifies the listeners of a change.
+func (f *Forest) emit(e ForestEvent) {
+	for _, fn := range f.listeners {
+		fn(e)
+	}
+}
+
+// Plant plants a new tree and returns it.
+func (f *Forest) Plant(pos pixel.Vec, frame int, scale, rot float64, flip bool) PlantedTree {
+	t := PlantedTree{X: pos.X, Y: pos.Y, Frame: frame, Scale: scale, Rotation: rot, Flip: flip, Planted: time.Now()}
+	f.Add(t)
+	return t
+}
+
+// Add adds existing trees (loaded, imported or redone) to the forest.
+func (f *Forest) Add(trees ...PlantedTree) {
+	for _, t := range trees {
+		f.Trees = append(f.Trees, t)
+		f.index.Insert(t.Pos(), len(f.Trees)-1)
+		f.sorted = nil
+		f.emit(ForestEvent{Type: EventPlant, Tree: &t})
+	}
+}
+
+// Insert puts a tree back at index i (or last, past the end), shifting the trees after it.
+func (f *Forest) Insert(i int, t PlantedTree) {
+	if i < 0 || i >= len(f.Trees) {
+		f.Add(t)
+		return
+	}
+	f.Trees = append(f.Trees[:i+1], f.Trees[i:]...)
+	f.Trees[i] = t
+	f.reindex()
+	f.emit(ForestEvent{Type: EventPlant, Tree: &t, Shifted: true})
+}
+
+// Remove removes the most recently added tree equal to t and reports whether there was one.
+func (f *Forest) Remove(t PlantedTree) bool {
+	for i := len(f.Trees) - 1; i >= 0; i-- {
+		if f.Trees[i] == t {
+			f.Trees = append(f.Trees[:i], f.Trees[i+1:]...)
+			f.reindex()
+			f.emit(ForestEvent{Type: EventRemove, Tree: &t})
+			return true
+		}
+	}
+	return false
+}
+
+// Move moves the tree at index i to pos, keeping its frame, scale, rotation and flip.
+func (f *Forest) Move(i int, pos pixel.Vec) {
+	from := f.Trees[i]
+	f.Trees[i].X, f.Trees[i].Y = pos.X, pos.Y
+	f.reindex()
+	f.emit(ForestEvent{Type: EventMove, Tree: &f.Trees[i], From: &from})
+}
+
+// SetLabel sets the text shown above the tree at index i (empty for none).
+func (f *Forest) SetLabel(i int, label string) {
+	from := f.Trees[i]
+	f.Trees[i].Label = label
+	f.emit(ForestEvent{Type: EventLabel, Tree: &f.Trees[i], From: &from})
+}
+
+// Place moves the trees at the given indices to the positions of the same rank, keeping
+// their frame, scale, rotation and flip. Indices past the last tree are skipped.
+func (f *Forest) Place(indices []int, positions []pixel.Vec) {
+	var events []ForestEvent
+	for k, i := range indices {
+		if i < 0 || i >= len(f.Trees) {
+			continue
+		}
+		from := f.Trees[i]
+		f.Trees[i].X, f.Trees[i].Y = positions[k].X, positions[k].Y
+		events = append(events, ForestEvent{Type: EventMove, Tree: &f.Trees[i], From: &from})
+	}
+	if len(events) == 0 {
+		return
+	}
+	f.reindex()
+	for _, e := range events {
+		f.emit(e)
+	}
+}
+
+// MoveIndices moves the trees at the given indices by delta, keeping their indices.
+func (f *Forest) MoveIndices(move map[int]bool, delta pixel.Vec) {
+	if len(move) == 0 || delta == pixel.ZV {
+		return
+	}
+	var events []ForestEvent
+	for i := range move {
+		from := f.Trees[i]
+		f.Trees[i].X, f.Trees[i].Y = from.X+delta.X, from.Y+delta.Y
+		events = append(events, ForestEvent{Type: EventMove, Tree: &f.Trees[i], From: &from})
+	}
+	f.reindex()
+	for _, e := range events {
+		f.emit(e)
+	}
+}
+
+// Near returns the indices of every tree closer than radius to pos.
+func (f *Forest) Near(pos pixel.Vec, radius float64) map[int]bool {
+	near := map[int]bool{}
+	f.index.Within(pos, radius, func(i int) { near[i] = true })
+	return near
+}
+
+// RemoveNear removes every tree closer than radius to pos and returns them.
+func (f *Forest) RemoveNear(pos pixel.Vec, radius float64) []PlantedTree {
+	return f.RemoveIndices(f.Near(pos, radius))
+}
+
+// RemoveIndices removes the trees at the given indices and returns them.
+func (f *Forest) RemoveIndices(remove map[int]bool) []PlantedTree {
+	if len(remove) == 0 {
+		return nil
+	}
+	var removed []PlantedTree
+	kept := f.Trees[:0]
+	for i, t := range f.Trees {
+		if remove[i] {
+			removed = append(removed, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	f.Trees = kept
+	f.reindex()
+	for i := range removed {
+		f.emit(ForestEvent{Type: EventRemove, Tree: &removed[i]})
+	}
+	return removed
+}
+
+// Nearest returns the index of the tree closest to pos, only considering trees closer than
+// radius. It reports false when there is none.
+func (f *Forest) Nearest(pos pixel.Vec, radius float64) (int, bool) {
+	return f.index.Nearest(pos, radius)
+}
+
+// InRect returns the indices of the trees inside r.
+func (f *Forest) InRect(r pixel.Rect) map[int]bool {
+	in := map[int]bool{}
+	for i, t := range f.Trees {
+		if r.Contains(t.Pos()) {
+			in[i] = true
+		}
+	}
+	return in
+}
+
+// Clear removes every tree.
+func (f *Forest) Clear() {
+	f.Trees = nil
+	f.reindex()
+	f.emit(ForestEvent{Type: EventClear})
+}
+
+// Count returns the number of trees in the forest.
+func (f *Forest) Count() int {
+	return len(f.Trees)
+}
+
+// reindex rebuilds the spatial index after trees were removed (which shifts their indices)
+// or moved. The batch has to be rebuilt too.
+func (f *Forest) reindex() {
+	f.dirty = true
+	f.sorted = nil
+	f.index = buildQuadTree(f.bounds, f.Trees)
+}
+
+// Draw draws a single tree into the batch.
+func (f *Forest) Draw(batch *pixel.Batch, t PlantedTree) {
+	tree := pixel.NewSprite(f.spritesheet, f.frames[t.Frame])
+	tree.Draw(batch, t.Matrix())
+}
+
+// SetDepthSort sets whether trees lower in the world are drawn in front of the ones above
+// them (the painter's algorithm), rather than in planting order.
+func (f *Forest) SetDepthSort(on bool) {
+	f.depthSort, f.dirty = on, true
+}
+
+// drawOrder returns the trees in the order they are drawn. The depth order is only sorted
+// again after the forest changed.
+func (f *Forest) drawOrder() []PlantedTree {
+	if !f.depthSort {
+		return f.Trees
+	}
+	if f.sorted == nil {
+		f.sorted = append([]PlantedTree(nil), f.Trees...)
+		sort.SliceStable(f.sorted, func(i, j int) bool { return f.sorted[i].Y > f.sorted[j].Y })
+	}
+	return f.sorted
+}
+
+// Rebuild clears the batch and redraws every tree into it.
+func (f *Forest) Rebuild(batch *pixel.Batch) {
+	batch.Clear()
+	for _, t := range f.drawOrder() {
+		f.Draw(batch, t)
+	}
+	f.drawn, f.dirty = len(f.Trees), false
+}
+
+// Sync brings the batch up to date with the forest at the least cost: nothing happens when the
+// forest didn't change, trees added since are drawn on top of the batch, and it is only
+// rebuilt when trees were removed or moved, or after RebuildAnimated. When depth sorted, new
+// trees may belong behind others, so the batch is rebuilt for them too.
+func (f *Forest) Sync(batch *pixel.Batch) {
+	if f.dirty || f.depthSort && f.drawn < len(f.Trees) {
+		f.Rebuild(batch)
+		return
+	}
+	for _, t := range f.Trees[f.drawn:] {
+		f.Draw(batch, t)
+	}
+	f.drawn = len(f.Trees)
+}
+
+// RebuildAnimated clears the batch and redraws the trees standing inside view with the frame and
+// matrix returned by pose. It runs every frame while trees are animated, so trees outside the view
+// are culled and a single sprite is reused for all of them. The next Sync rebuilds the batch
+// with the trees at rest.
+func (f *Forest) RebuildAnimated(batch *pixel.Batch, view pixel.Rect, pose func(t PlantedTree) (int, pixel.Matrix)) {
+	batch.Clear()
+	sprite := pixel.NewSprite(f.spritesheet, f.spritesheet.Bounds())
+	for _, t := range f.drawOrder() {
+		if !view.Contains(t.Pos()) {
+			continue
+		}
+		frame, matrix := pose(t)
+		sprite.Set(f.spritesheet, f.frames[frame])
+		sprite.Draw(batch, matrix)
+	}
+	f.dirty = true
+}
+
+// SavedCamera is the view saved along with a forest, to resume where it was left.
+type SavedCamera struct {
+	X        float64 `json:"x"`                  // World position X at the center of the window
+	Y        float64 `json:"y"`                  // World position Y at the center of the window
+	Zoom     float64 `json:"zoom"`               // Zoom level
+	Rotation float64 `json:"rotation,omitempty"` // Rotation of the view in radians
+}
+
+// saveFile is the layout of a save file. Older saves are a bare array of trees, without the
+// camera.
+type saveFile struct {
+	Camera *SavedCamera  `json:"camera,omitempty"`
+	Trees  []PlantedTree `json:"trees"`
+}
+
+// saveForest writes the planted trees and the camera, if not nil, to a JSON file, creating its
+// directory if needed.
+func saveForest(path string, trees []PlantedTree, cam *SavedCamera) error {
+	// An empty forest is still saved with an empty array of trees, not null
+	if trees == nil {
+		trees = []PlantedTree{}
+	}
+	data, err := json.MarshalIndent(saveFile{Camera: cam, Trees: trees}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// loadForest reads the trees and camera saved by saveForest, the camera is nil when none was
+// saved. A missing file is not an error and yields an empty forest.
+func loadForest(path string) ([]PlantedTree, *SavedCamera, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Saves from before the camera was saved are just the array of trees
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var trees []PlantedTree
+		if err := json.Unmarshal(data, &trees); err != nil {
+			return nil, nil, err
+		}
+		return trees, nil, nil
+	}
+	var save saveFile
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, nil, err
+	}
+	return save.Trees, save.Camera, nil
+}
+
+// autosaveNewer reports whether the autosave file was written after the save file (or there
+// is no save file), so that it holds work that wasn't saved.
+func autosaveNewer(autosave, save string) bool {
+	a, err := os.Stat(autosave)
+	if err != nil {
+		return false
+	}
+	s, err := os.Stat(save)
+	return err != nil || a.ModTime().After(s.ModTime())
+}
+
+// repairForest makes loaded trees safe to draw: frame indices are clamped to the
+// available frames and missing scales get the default scale.
+func repairForest(trees []PlantedTree, frameCount int) []PlantedTree {
+	for i := range trees {
+		if trees[i].Frame < 0 {
+			trees[i].Frame = 0
+		}
+		if trees[i].Frame >= frameCount {
+			trees[i].Frame = frameCount - 1
+		}
+		if trees[i].Scale <= 0 {
+			trees[i].Scale = defaultTreeScale
+		}
+	}
+	return trees
+}
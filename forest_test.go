@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestForestPlant(t *testing.T) {
+	f := testForest(0)
+	var events []ForestEvent
+	f.Subscribe(func(e ForestEvent) { events = append(events, e) })
+
+	var want []PlantedTree
+	for i := 0; i < 20; i++ {
+		tree := f.Plant(pixel.V(float64(i*7), 3), i%3, 2, 0.5, i%2 == 0)
+		if tree.Pos() != pixel.V(float64(i*7), 3) || tree.Frame != i%3 || tree.Scale != 2 || tree.Rotation != 0.5 || tree.Flip != (i%2 == 0) {
+			t.Fatalf("planted %+v", tree)
+		}
+		if tree.Planted.IsZero() {
+			t.Fatal("planted tree without a planting time")
+		}
+		want = append(want, tree)
+		if f.Count() != i+1 {
+			t.Fatalf("count = %d after %d trees", f.Count(), i+1)
+		}
+	}
+	checkForest(t, f, want)
+
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Type != EventPlant || *e.Tree != want[i] || e.Shifted {
+			t.Fatalf("event %d = %+v", i, e)
+		}
+	}
+}
+
+func TestForestRemove(t *testing.T) {
+	tree := PlantedTree{X: 10, Y: 10, Frame: 1, Scale: 4}
+	other := PlantedTree{X: 20, Y: 10, Frame: 2, Scale: 4}
+	tests := []struct {
+		name   string
+		trees  []PlantedTree
+		remove PlantedTree
+		ok     bool
+		want   []PlantedTree
+	}{
+		{"empty", nil, tree, false, nil},
+		{"only tree", []PlantedTree{tree}, tree, true, nil},
+		{"missing", []PlantedTree{other}, tree, false, []PlantedTree{other}},
+		{"first", []PlantedTree{tree, other}, tree, true, []PlantedTree{other}},
+		{"last", []PlantedTree{other, tree}, tree, true, []PlantedTree{other}},
+		// Of equal trees, the most recently added one goes
+		{"duplicate", []PlantedTree{tree, other, tree, other}, tree, true, []PlantedTree{tree, other, other}},
+		{"same spot, other frame", []PlantedTree{{X: 10, Y: 10, Frame: 2, Scale: 4}}, tree, false, []PlantedTree{{X: 10, Y: 10, Frame: 2, Scale: 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testForest(0)
+			f.Add(tt.trees...)
+			var events []ForestEvent
+			f.Subscribe(func(e ForestEvent) { events = append(events, e) })
+
+			if ok := f.Remove(tt.remove); ok != tt.ok {
+				t.Fatalf("Remove = %v, want %v", ok, tt.ok)
+			}
+			if f.Count() != len(tt.want) {
+				t.Fatalf("count = %d, want %d", f.Count(), len(tt.want))
+			}
+			if !reflect.DeepEqual(snapshot(f), tt.want) {
+				t.Fatalf("trees = %v, want %v", f.Trees, tt.want)
+			}
+			if tt.ok && (len(events) != 1 || events[0].Type != EventRemove || *events[0].Tree != tt.remove) {
+				t.Fatalf("events = %+v, want a single removal", events)
+			}
+			if !tt.ok && len(events) > 0 {
+				t.Fatalf("events = %+v, want none", events)
+			}
+		})
+	}
+}
+
+func TestForestCount(t *testing.T) {
+	f := testForest(5)
+	if f.Count() != 5 {
+		t.Fatalf("count = %d, want 5", f.Count())
+	}
+	f.RemoveIndices(map[int]bool{0: true, 3: true})
+	if f.Count() != 3 {
+		t.Fatalf("count = %d after removing 2, want 3", f.Count())
+	}
+	f.Insert(1, PlantedTree{Scale: 4})
+	if f.Count() != 4 {
+		t.Fatalf("count = %d after inserting 1, want 4", f.Count())
+	}
+	f.Clear()
+	if f.Count() != 0 {
+		t.Fatalf("count = %d after clearing, want 0", f.Count())
+	}
+}
+
+// benchmarkForest returns a forest of n random trees over the world, drawn from a sheet of
+// four frames, and the batch it's drawn into.
+func benchmarkForest(n int) (*Forest, *pixel.Batch) {
+	sheet := pixel.MakePictureData(pixel.R(0, 0, 128, 32))
+	f := NewForest(worldBounds, sheet, cutFrames(sheet.Bounds(), 32))
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		pos := pixel.V(worldBounds.Min.X+rng.Float64()*worldBounds.W(), worldBounds.Min.Y+rng.Float64()*worldBounds.H())
+		f.Add(PlantedTree{X: pos.X, Y: pos.Y, Frame: rng.Intn(4), Scale: defaultTreeScale})
+	}
+	return f, pixel.NewBatch(&pixel.TrianglesData{}, sheet)
+}
+
+// benchmarkAnimated draws a swaying forest of 10000 trees into its batch once per iteration,
+// culling the ones outside view.
+func benchmarkAnimated(b *testing.B, view pixel.Rect) {
+	f, batch := benchmarkForest(10000)
+	pose := func(t PlantedTree) (int, pixel.Matrix) { return t.Frame, t.Matrix().Rotated(t.Pos(), 0.1) }
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.RebuildAnimated(batch, view, pose)
+	}
+}
+
+func BenchmarkAnimatedAllVisible(b *testing.B) {
+	benchmarkAnimated(b, worldBounds)
+}
+
+func BenchmarkAnimatedCulled(b *testing.B) {
+	// The view of a 1024x768 window at zoom 1
+	benchmarkAnimated(b, pixel.R(-512, -384, 512, 384))
+}
+
+func BenchmarkBatchRebuild(b *testing.B) {
+	f, batch := benchmarkForest(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Rebuild(batch)
+	}
+}
+
+func BenchmarkBatchSyncUnchanged(b *testing.B) {
+	f, batch := benchmarkForest(10000)
+	f.Rebuild(batch)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Sync(batch)
+	}
+}
+
+func BenchmarkBatchSyncPlanting(b *testing.B) {
+	// A tree planted every frame is drawn on top of the batch
+	f, batch := benchmarkForest(10000)
+	f.Rebuild(batch)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(PlantedTree{X: float64(i % 1000), Y: 0, Scale: defaultTreeScale})
+		f.Sync(batch)
+	}
+}
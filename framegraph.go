@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// frameBudget is the duration of a frame at 60 FPS, marked on the frame time graph.
+const frameBudget = 1.0 / 60
+
+// frameGraph remembers the durations of the latest frames, in a ring buffer, to graph them.
+type frameGraph struct {
+	times []float64
+	next  int
+}
+
+// newFrameGraph returns a graph of the latest n frames.
+func newFrameGraph(n int) *frameGraph {
+	return &frameGraph{times: make([]float64, n)}
+}
+
+// add records a frame that took dt seconds.
+func (g *frameGraph) add(dt float64) {
+	g.times[g.next] = dt
+	g.next = (g.next + 1) % len(g.times)
+}
+
+// draw pushes the graph into imd over a dark background filling r, oldest frame on the left.
+// The scale fits twice the 60 FPS budget, or the slowest frame when it is longer, and the
+// budget is marked by a horizontal line. imd is expected to be drawn in screen space.
+func (g *frameGraph) draw(imd *imdraw.IMDraw, r pixel.Rect) {
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.5))
+	imd.Push(r.Min, r.Max)
+	imd.Rectangle(0)
+
+	top := 2 * frameBudget
+	for _, dt := range g.times {
+		top = math.Max(top, dt)
+	}
+	y := func(dt float64) float64 { return r.Min.Y + r.H()*dt/top }
+
+	imd.Color = pixel.RGB(0.9, 0.3, 0.2)
+	imd.Push(pixel.V(r.Min.X, y(frameBudget)), pixel.V(r.Max.X, y(frameBudget)))
+	imd.Line(1)
+
+	imd.Color = pixel.RGB(0.5, 1, 0.4)
+	step := r.W() / float64(len(g.times)-1)
+	for i := range g.times {
+		dt := g.times[(g.next+i)%len(g.times)]
+		imd.Push(pixel.V(r.Min.X+float64(i)*step, y(dt)))
+	}
+	imd.Line(1)
+}
@@ -0,0 +1,1954 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// sceneTarget is where the world is drawn, the window or an offscreen canvas.
+type sceneTarget interface {
+	pixel.BasicTarget
+	Clear(c color.Color)
+}
+
+// countLabel is what the tree count label shows, so that it is only rewritten when it changes.
+type countLabel struct {
+	trees, brush, seeds int
+	recording, full     bool
+	search              string
+	matches             int
+}
+
+// Game is a game of planting trees in a window. Every frame, HandleInput reacts to the keyboard
+// and the mouse, Update advances the game and Draw renders it.
+type Game struct {
+	opts              options          // Settings given on the command line, the config changed by the settings overlay
+	keys              Keybindings      // Buttons of the actions
+	camera            *Camera          // Camera looking at the world
+	cam               pixel.Matrix     // Camera matrix of the frame, taken before the input moves the camera
+	quit              bool             // The player quit, or the benchmark is over
+	screenshotDue     bool             // A screenshot is saved once the frame is drawn
+	forest            *Forest          // Planted trees (source of truth for the batch)
+	treesFrames       []pixel.Rect     // Tree frames cut from the spritesheet
+	dotColors         []pixel.RGBA     // Dot color of each frame, for the trees drawn as dots when zoomed far out
+	hoverRadius       float64          // Distance from a tree within which the cursor is over it
+	rng               *rand.Rand       // Random source for the sprite, rotation and scale of planted trees
+	start             time.Time        // When the game started, for the wind sway
+	snd               *sound           // Background music and plant sound (nil when disabled)
+	sess              *session         // Collaborative planting session, if any
+	replaying         *replay          // Recording being replayed, if any
+	bench             *benchmark       // Benchmark planting trees at random positions, if any
+	seedStock         *seeds           // Seeds spent to plant trees (nil unless the seed economy is on)
+	unlocks           *achievements    // Milestones and varieties left to congratulate
+	settings          []setting        // Entries of the settings overlay, changing the live settings
+	weatherFx         *weather         // Rain or snow falling over the window
+	density           *heatmap         // Tree density heatmap, shaded again after the forest changes
+	mini              minimap          // Minimap placement in the window
+	list              treeList         // Tree list placement in the window
+	palette           palette          // Palette placement in the window
+	batch             *pixel.Batch     // First batch (trees)
+	paletteBatch      *pixel.Batch     // Thumbnails of the palette, drawn in screen space
+	frameSprites      []*pixel.Sprite  // Sprite of each frame, for the palette thumbnails
+	groundTexture     pixel.Picture    // Ground texture tiled under the trees, if any
+	ground            *pixel.Batch     // Tiles of the ground texture (nil for the solid color)
+	canvas            *pixelgl.Canvas  // Offscreen canvas the world is drawn to when supersampling (nil otherwise)
+	setSmooth         func(bool)       // Sets the sprite filtering of the window
+	overlay           *imdraw.IMDraw   // Shapes drawn over the world (grid)
+	sky               *imdraw.IMDraw   // Background gradient, drawn in screen space behind everything
+	dots              *imdraw.IMDraw   // Trees drawn as dots when zoomed far out
+	shadows           *imdraw.IMDraw   // Tree shadows, drawn under the trees
+	effects           *imdraw.IMDraw   // Falling leaves, drawn over the trees
+	hud               *imdraw.IMDraw   // Shapes drawn in screen space over everything (minimap)
+	menu              *imdraw.IMDraw   // Pause menu shapes, drawn over the HUD
+	basicAtlas        *text.Atlas      // Font of every text but the HUD's
+	hudAtlas          *text.Atlas      // Font of the tutorial and the tree count, the -font one when given
+	hudFont           bool             // The HUD font is the -font one, drawn at its own size rather than scaled up
+	statsTxt          *text.Text       // Statistics panel text, drawn in screen space
+	tooltipTxt        *text.Text       // Tooltip of the tree under the cursor, drawn in screen space
+	coordsTxt         *text.Text       // World position of the cursor, drawn in screen space
+	listTxt           *text.Text       // Tree list panel text, drawn in screen space
+	settingsTxt       *text.Text       // Settings overlay text, drawn in screen space
+	bannerTxt         *text.Text       // Achievement banner text, drawn in screen space
+	menuTxt           *text.Text       // Pause menu text, drawn in screen space
+	treeCountLabel    *text.Text       // Tree count label, moved to the top-left corner of the view every frame
+	countShown        countLabel       // What the tree count label was last written with
+	statusLabel       *text.Text       // Status label under the tree count, moved below it every frame
+	statusShown       string           // What the status label was last written with
+	basicTxt          *text.Text       // Tutorial text, positioned every frame from the window size
+	helpOn            bool             // Draw the tutorial text
+	labelTxt          *text.Text       // Tree labels, drawn in world space
+	homePos           pixel.Vec        // Default camera position (the tutorial is laid out around it)
+	initialFontScale  float64          // Initial font scale
+	frameTimes        []float64        // Durations of the latest frames, for the FPS average
+	frameIndex        int              // Next slot to fill in frameTimes
+	frameCount        int              // Number of filled slots in frameTimes
+	frameSum          float64          // Sum of frameTimes
+	frameTimeGraph    *frameGraph      // Latest frame durations, for the frame time graph
+	graphOn           bool             // Show the frame time graph
+	autosaveTick      <-chan time.Time // Tick to autosave the forest (nil when autosave is off)
+	autosaved         chan error       // Result of the autosave running in the background
+	autosaving        bool             // An autosave is being written
+	changedSinceSave  bool             // The forest changed since the last autosave
+	recoverUntil      time.Time        // Time until which Ctrl+R recovers the autosave
+	csvPath           string           // Forest CSV export file
+	undoStack         []action         // Recent changes of the forest that can be undone
+	redoStack         []action         // Undone changes that can be made again
+	drag              action           // Action of the drag going on, extended by each step of the drag
+	statusMsg         string           // Short status message shown under the tree count
+	statusUntil       time.Time        // Time until which the status message is shown
+	banners           []string         // Achievement messages waiting to be shown
+	banner            string           // Achievement message shown at the top of the window
+	bannerUntil       time.Time        // Time until which the achievement message is shown
+	brushFrame        int              // Selected tree frame to plant (-1 means random)
+	brushSize         int              // Trees planted by a click, scattered around the cursor
+	erasing           bool             // Left clicks remove the trees under the brush instead of planting
+	erasingDrag       bool             // Dragging with the left button to erase trees
+	panLastMouse      pixel.Vec        // Mouse position during the previous frame of a middle-drag pan
+	gridSnap          bool             // Snap planted trees to the grid
+	gridSize          float64          // Grid cell size in world units
+	gridOn            bool             // Show the scale grid, whether or not trees snap to it
+	heatmapOn         bool             // Shade the world by tree density
+	vignetteOn        bool             // Fade the edges of the window toward the background color
+	rotateTrees       bool             // Give planted trees a small random rotation
+	scaleTrees        bool             // Give planted trees a random size
+	flipTrees         bool             // Mirror planted trees horizontally
+	minSpacing        float64          // Minimum distance between trees (0 disables)
+	plantCooldown     time.Duration    // Minimum time between two clicks planting a tree
+	lastPlantedByUser time.Time        // When a tree was last planted with plantTree, for the cooldown
+	painting          bool             // Dragging with the left button to paint trees
+	paintLast         pixel.Vec        // World position of the last tree painted during the drag
+	selecting         bool             // Dragging out a rectangle to fill with Shift held
+	selectStart       pixel.Vec        // World position where the rectangle drag started
+	movingTree        int              // Index of the tree dragged with Ctrl held (-1 when none)
+	selected          map[int]bool     // Indices of the trees selected with a Ctrl+drag rectangle
+	boxSelecting      bool             // Dragging out a selection rectangle with Ctrl held
+	boxStart          pixel.Vec        // World position where the selection rectangle drag started
+	movingSelection   bool             // Dragging the selected trees with Ctrl held
+	moveLast          pixel.Vec        // World position of the cursor during the previous frame of the selection drag
+	clipboard         []PlantedTree    // Trees copied with Ctrl+C, relative to their centroid
+	labeling          int              // Index of the tree whose label is being typed (-1 when none)
+	labelText         string           // Label typed so far
+	searching         bool             // Typing the search query
+	search            string           // Search query, the matching trees are highlighted (empty for none)
+	matches           []int            // Indices of the trees matching the search, see searchMatches
+	matchesDirty      bool             // The forest or the search changed since the matches were found
+	nextMatch         int              // Number of matches visited with Enter, the next is the one after
+	paused            bool             // Game halted with the pause menu open
+	pauseSelected     int              // Highlighted pause menu entry
+	settingsOn        bool             // Show the settings overlay
+	settingSelected   int              // Highlighted entry of the settings overlay
+	settingsChanged   bool             // Whether a setting was changed since the overlay was opened
+	clearArmedUntil   time.Time        // Time until which a second Delete press clears the forest
+	timeOfDay         float64          // Time of day in [0, 1), 0 is noon
+	dayPaused         bool             // Stop the day/night cycle
+	grassColor        pixel.RGBA       // Background color, grass green #4F8227 unless configured
+	gradientOn        bool             // Draw the background gradient instead of the flat grass color
+	gradientTop       pixel.RGBA       // Top color of the background gradient
+	gradientBottom    pixel.RGBA       // Bottom color of the background gradient
+	windOn            bool             // Animate the trees swaying in the wind
+	growDuration      time.Duration    // Time for a sapling to grow
+	popDuration       time.Duration    // Time for a planted tree to settle from its pop
+	lastPlantAt       time.Time        // When the last tree was planted
+	minimapOn         bool             // Show the minimap
+	statsOn           bool             // Show the statistics panel
+	coordsOn          bool             // Show the world position of the cursor
+	plantTimes        plantRate        // Recent plant times for the planting rate
+	rec               *recorder        // Recording of the planted trees, while recording
+	leavesOn          bool             // Burst leaves out of planted trees
+	leaves            particles        // Falling leaves
+	touring           *tour            // Camera tour of the forest, while touring
+	gliding           *glide           // Camera glide to a tree picked in the tree list, while gliding
+	listOn            bool             // Show the tree list panel
+	paletteOn         bool             // Show the palette of tree frames
+	listScroll        int              // Index of the first tree shown in the tree list
+}
+
+// NewGame sets up a game in the window: it loads the spritesheet and the saved forest (or the
+// replay), and starts the collaborative session and the spectator server, if any.
+func NewGame(opts options, win *pixelgl.Window) (*Game, error) {
+	g, err := newGame(opts, win.Bounds(), win.SetSmooth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offscreen canvas the world is drawn to when supersampling, smoothly downsampled to the
+	// window for anti-aliased edges
+	if g.opts.config.Supersample > 1 {
+		g.canvas = pixelgl.NewCanvas(win.Bounds())
+		g.canvas.SetSmooth(true)
+	}
+	return g, nil
+}
+
+// newGame sets up everything of a game but its window, for a window of the given bounds, so
+// that the game can run without one. setSmooth sets the sprite filtering of the window.
+func newGame(opts options, window pixel.Rect, setSmooth func(bool)) (*Game, error) {
+	g := &Game{
+		opts:             opts,
+		keys:             opts.keys,
+		setSmooth:        setSmooth,
+		homePos:          window.Center(),
+		initialFontScale: opts.config.InitialFontScale,
+		frameTimes:       make([]float64, opts.config.FPSSamples),
+		frameTimeGraph:   newFrameGraph(opts.config.GraphSamples),
+		autosaved:        make(chan error, 1),
+		csvPath:          "forest.csv",
+		brushFrame:       -1,
+		brushSize:        1,
+		gridSize:         opts.config.GridSize,
+		rotateTrees:      true,
+		scaleTrees:       true,
+		minSpacing:       opts.config.MinSpacing,
+		plantCooldown:    seconds(opts.config.PlantCooldown),
+		movingTree:       -1,
+		labeling:         -1,
+		selected:         map[int]bool{},
+		pauseSelected:    pauseResume,
+		gradientOn:       opts.config.GradientTop != "",
+		windOn:           true,
+		growDuration:     seconds(opts.config.GrowthDuration),
+		popDuration:      seconds(opts.config.PopDuration),
+		minimapOn:        true,
+		leavesOn:         true,
+		helpOn:           opts.config.ShowHelp,
+		countShown:       countLabel{trees: -1},
+	}
+	g.grassColor, _ = parseColor(opts.config.BackgroundColor)
+	g.gradientTop, _ = parseColor(opts.config.GradientTop)
+	g.gradientBottom, _ = parseColor(opts.config.GradientBottom)
+
+	// Camera looking at the world
+	g.camera = &Camera{
+		Pos:          window.Center(),
+		ZoomLevel:    g.opts.config.CamZoom,
+		TargetZoom:   g.opts.config.CamZoom,
+		ZoomEasing:   g.opts.config.CamZoomEasing,
+		MinZoom:      g.opts.config.MinZoom,
+		MaxZoom:      g.opts.config.MaxZoom,
+		Speed:        g.opts.config.CamSpeed,
+		Acceleration: g.opts.config.CamAcceleration,
+		ZoomSpeed:    g.opts.config.CamZoomSpeed,
+		ShakeScale:   g.opts.config.CamShake,
+		Bounds:       window,
+	}
+	// Start where the config says, inside the world
+	if len(g.opts.config.CamPos) == 2 {
+		g.camera.Pos = pixel.V(g.opts.config.CamPos[0], g.opts.config.CamPos[1])
+	}
+	g.camera.Clamp(worldBounds)
+
+	// Define text fonts
+	g.basicAtlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	// The HUD is drawn with the -font font at its size when given, crisper than the bitmap font
+	// scaled up. A font that can't be loaded falls back to the bitmap font
+	g.hudAtlas = g.basicAtlas
+	if g.opts.font != "" {
+		if face, err := loadFontFace(g.opts.font, g.opts.fontSize); err != nil {
+			fmt.Fprintf(os.Stderr, "trees: warning: cannot load font, using the bitmap font: %v\n", err)
+		} else {
+			g.hudAtlas, g.hudFont = text.NewAtlas(face, text.ASCII), true
+		}
+	}
+	// Statistics panel text, drawn in screen space
+	g.statsTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Tooltip of the tree under the cursor, drawn in screen space
+	g.tooltipTxt = text.New(pixel.ZV, g.basicAtlas)
+	// World position of the cursor, drawn in screen space
+	g.coordsTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Tree list panel text, drawn in screen space
+	g.listTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Settings overlay text, drawn in screen space
+	g.settingsTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Achievement banner text, drawn in screen space
+	g.bannerTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Pause menu text, drawn in screen space
+	g.menuTxt = text.New(pixel.ZV, g.basicAtlas)
+	// Tree count label, moved to the top-left corner of the view every frame
+	g.treeCountLabel = text.New(pixel.ZV, g.hudAtlas)
+	g.statusLabel = text.New(pixel.ZV, g.basicAtlas)
+	// Tutorial text, positioned every frame from the window size
+	g.basicTxt = text.New(pixel.ZV, g.hudAtlas)
+	// Tree labels, drawn in world space above the trees
+	g.labelTxt = text.New(pixel.ZV, g.basicAtlas)
+
+	// Tutorial text from the -helptext file when given. A file that can't be read falls back to
+	// the controls
+	customHelp := false
+	if g.opts.helpText != "" {
+		if help, err := os.ReadFile(g.opts.helpText); err != nil {
+			fmt.Fprintf(os.Stderr, "trees: warning: cannot load help text, showing the controls: %v\n", err)
+		} else {
+			fmt.Fprint(g.basicTxt, string(help))
+			customHelp = true
+		}
+	}
+	if !customHelp {
+		// Author variable and print text with fmt, naming the keys as bound
+		author := "Jordan"
+		fmt.Fprintln(g.basicTxt, "Controls:")
+		fmt.Fprintf(g.basicTxt, "- %s/%s/%s/%s: Move Camera\n", g.keys["pan_up"], g.keys["pan_down"], g.keys["pan_left"], g.keys["pan_right"])
+		fmt.Fprintln(g.basicTxt, "- Middle Drag: Pan Camera")
+		fmt.Fprintf(g.basicTxt, "- Scroll, %s/%s: Zoom\n", g.keys["zoom_in"], g.keys["zoom_out"])
+		fmt.Fprintln(g.basicTxt, "- Left Click: Plant Tree")
+		fmt.Fprintf(g.basicTxt, "- %s: Plant at Center\n", g.keys["plant_center"])
+		fmt.Fprintln(g.basicTxt, "- Left Drag: Paint Trees")
+		fmt.Fprintln(g.basicTxt, "- Shift+Drag: Fill Rectangle")
+		fmt.Fprintln(g.basicTxt, "- Ctrl+Drag: Move Tree / Select Trees")
+		fmt.Fprintln(g.basicTxt, "- 1-9: Select Tree, 0: Random")
+		fmt.Fprintf(g.basicTxt, "- %s: Grid Snap\n", g.keys["grid_snap"])
+		fmt.Fprintf(g.basicTxt, "- %s: Show Grid\n", g.keys["grid"])
+		fmt.Fprintf(g.basicTxt, "- %s: Density Heatmap\n", g.keys["heatmap"])
+		fmt.Fprintf(g.basicTxt, "- %s: Random Rotation\n", g.keys["rotation"])
+		fmt.Fprintf(g.basicTxt, "- %s: Random/Uniform Size\n", g.keys["size"])
+		fmt.Fprintf(g.basicTxt, "- %s: Flip Trees\n", g.keys["flip"])
+		fmt.Fprintf(g.basicTxt, "- %s/%s: Brush Size\n", g.keys["brush_smaller"], g.keys["brush_larger"])
+		fmt.Fprintf(g.basicTxt, "- %s: Eraser\n", g.keys["eraser"])
+		fmt.Fprintf(g.basicTxt, "- %s: Pause Day/Night\n", g.keys["pause_day"])
+		fmt.Fprintf(g.basicTxt, "- %s: Wind\n", g.keys["wind"])
+		fmt.Fprintf(g.basicTxt, "- %s: Falling Leaves\n", g.keys["leaves"])
+		fmt.Fprintf(g.basicTxt, "- %s: Minimap\n", g.keys["minimap"])
+		fmt.Fprintf(g.basicTxt, "- %s: Mute\n", g.keys["mute"])
+		fmt.Fprintf(g.basicTxt, "- %s: Statistics\n", g.keys["stats"])
+		fmt.Fprintf(g.basicTxt, "- %s: Tree List\n", g.keys["tree_list"])
+		fmt.Fprintf(g.basicTxt, "- %s: Tree Palette\n", g.keys["palette"])
+		fmt.Fprintf(g.basicTxt, "- %s: Weather\n", g.keys["weather"])
+		fmt.Fprintf(g.basicTxt, "- %s: Vignette\n", g.keys["vignette"])
+		fmt.Fprintf(g.basicTxt, "- %s: Cursor Position\n", g.keys["coords"])
+		fmt.Fprintf(g.basicTxt, "- %s: Frame Time Graph\n", g.keys["frame_graph"])
+		fmt.Fprintf(g.basicTxt, "- %s: Smooth/Pixel Sprites\n", g.keys["smooth"])
+		fmt.Fprintf(g.basicTxt, "- %s: Depth Sorting\n", g.keys["depth_sort"])
+		fmt.Fprintf(g.basicTxt, "- %s: Tour the Forest\n", g.keys["tour"])
+		fmt.Fprintf(g.basicTxt, "- %s: Label Tree\n", g.keys["label"])
+		fmt.Fprintf(g.basicTxt, "- %s, %s: Search Trees, Next Match\n", g.keys["search"], g.keys["search_next"])
+		fmt.Fprintf(g.basicTxt, "- %s/%s: Rotate View, %s: Reset\n", g.keys["rotate_left"], g.keys["rotate_right"], g.keys["reset_rotation"])
+		fmt.Fprintf(g.basicTxt, "- %s: Record Session\n", g.keys["record"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Save Forest\n", g.keys["save"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Export CSV\n", g.keys["export"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Import CSV\n", g.keys["import"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s/%s: Copy/Paste Selection\n", g.keys["copy"], g.keys["paste"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Undo\n", g.keys["undo"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Redo\n", g.keys["redo"])
+		fmt.Fprintf(g.basicTxt, "- %s (x2): Clear Forest\n", g.keys["clear"])
+		fmt.Fprintf(g.basicTxt, "- %s: Pause Menu\n", g.keys["pause"])
+		fmt.Fprintf(g.basicTxt, "- %s: Settings\n", g.keys["settings"])
+		fmt.Fprintf(g.basicTxt, "- Ctrl+%s: Quit\n", g.keys["quit"])
+		fmt.Fprintf(g.basicTxt, "- %s: Fullscreen\n", g.keys["fullscreen"])
+		fmt.Fprintf(g.basicTxt, "- %s: Screenshot\n", g.keys["screenshot"])
+		fmt.Fprintf(g.basicTxt, "- %s: Hide/Show Help\n", g.keys["help"])
+		fmt.Fprintf(g.basicTxt, "- %s: Forest Map\n", g.keys["map"])
+		fmt.Fprintln(g.basicTxt, "\nJust have fun planting trees!")
+		fmt.Fprintf(g.basicTxt, "- %s", author)
+	}
+
+	// Load the spritesheet image for trees
+	spritesheet, err := loadPicture(g.opts.spritesheet)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load spritesheet (choose another with -spritesheet): %v", err)
+	}
+
+	// First batch (trees)
+	g.batch = pixel.NewBatch(&pixel.TrianglesData{}, spritesheet)
+	// Shapes drawn over the world (grid)
+	g.overlay = imdraw.New(nil)
+	// Background gradient, drawn in screen space behind everything
+	g.sky = imdraw.New(nil)
+	// Tiles of the ground texture, if any. A texture that can't be loaded falls back to the solid color
+	if g.opts.ground != "" {
+		if g.groundTexture, err = loadPicture(g.opts.ground); err != nil {
+			fmt.Fprintf(os.Stderr, "trees: warning: cannot load ground texture, using the solid color: %v\n", err)
+		} else {
+			g.ground = pixel.NewBatch(&pixel.TrianglesData{}, g.groundTexture)
+		}
+	}
+	// Trees drawn as dots when zoomed far out
+	g.dots = imdraw.New(nil)
+	// Tree shadows, drawn under the trees
+	g.shadows = imdraw.New(nil)
+	// Falling leaves, drawn over the trees
+	g.effects = imdraw.New(nil)
+	// Shapes drawn in screen space over everything (minimap)
+	g.hud = imdraw.New(nil)
+	// Pause menu shapes, drawn over the HUD
+	g.menu = imdraw.New(nil)
+
+	// Prepare tree frames from the spritesheet (cut them from the spritesheet)
+	size := float64(g.opts.frameSize)
+	sheet := spritesheet.Bounds()
+	if math.Mod(sheet.W(), size) != 0 || math.Mod(sheet.H(), size) != 0 {
+		fmt.Fprintf(os.Stderr, "trees: warning: spritesheet size %vx%v is not a multiple of the %dpx frame size, partial frames are ignored\n", sheet.W(), sheet.H(), g.opts.frameSize)
+	}
+	g.treesFrames = cutFrames(sheet, size)
+	// Every tree needs a frame to be drawn with, a single one is enough
+	if len(g.treesFrames) == 0 {
+		return nil, fmt.Errorf("spritesheet %s is smaller than a %dpx frame (choose another with -spritesheet or -framesize)", g.opts.spritesheet, g.opts.frameSize)
+	}
+
+	// Background music and plant sound, if any. A sound that can't be played only disables the sound
+	g.snd, err = loadSound(g.opts.music, g.opts.plantSound, g.opts.config.Volume, g.opts.config.PlantPitch, g.opts.seed)
+	if err != nil {
+		g.showStatus(fmt.Sprintf("Sound disabled: %v", err), 5*time.Second)
+		g.snd = nil
+	}
+
+	// Random source for the sprite, rotation and scale of planted trees, so that a fixed
+	// seed and the same clicks always give the same forest
+	g.rng = rand.New(rand.NewSource(g.opts.seed))
+
+	// Thumbnails of the frames in the palette
+	g.paletteBatch = pixel.NewBatch(&pixel.TrianglesData{}, spritesheet)
+	for _, frame := range g.treesFrames {
+		g.frameSprites = append(g.frameSprites, pixel.NewSprite(spritesheet, frame))
+	}
+
+	// Dot color of each frame, for the trees drawn as dots when zoomed far out
+	g.dotColors = frameColors(spritesheet, g.treesFrames)
+
+	// Planted trees (source of truth for the batch)
+	g.forest = NewForest(worldBounds, spritesheet, g.treesFrames)
+	g.forest.SetDepthSort(g.opts.config.DepthSort)
+
+	// Load the recording to replay, which starts from an empty forest, or else the
+	// previously saved forest, if any (unless one is generated or it's a benchmark)
+	if g.opts.replay != "" {
+		g.replaying, err = loadReplay(g.opts.replay, g.opts.config.ReplaySpeed)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load replay: %v", err)
+		}
+	} else if g.opts.generate == 0 && g.opts.benchmark == 0 {
+		saved, savedCam, err := loadForest(savePath)
+		if err != nil {
+			g.showStatus(fmt.Sprintf("Load failed: %v", err), 3*time.Second)
+		}
+		g.forest.Add(repairForest(saved, len(g.treesFrames))...)
+		// Resume the view where it was saved, unless the flags say where to start
+		if savedCam != nil && !g.opts.camSet {
+			g.restoreCamera(savedCam)
+		}
+		// Offer to recover the autosave when it has work that wasn't saved
+		if autosaveNewer(autosavePath, savePath) {
+			timeout := 10 * time.Second
+			g.showStatus(fmt.Sprintf("Unsaved work found: Ctrl+%s to recover %s", g.keys["recover"], autosavePath), timeout)
+			g.recoverUntil = time.Now().Add(timeout)
+		}
+	}
+
+	// Autosave periodically when enabled, only when the forest changed since the last time
+	if g.opts.config.Autosave > 0 {
+		g.autosaveTick = time.Tick(seconds(g.opts.config.Autosave))
+	}
+	g.forest.Subscribe(func(ForestEvent) { g.changedSinceSave = true })
+
+	// Record every tree planted while recording, whoever planted it
+	g.forest.Subscribe(func(e ForestEvent) {
+		if g.rec != nil && e.Type == EventPlant {
+			g.rec.Record(*e.Tree)
+		}
+	})
+
+	// Collaborative planting session, if any
+	switch {
+	case g.opts.server != "":
+		g.sess, err = serve(g.opts.server)
+	case g.opts.connect != "":
+		g.sess, err = connect(g.opts.connect)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot start session: %v", err)
+	}
+
+	// Live forest view for web spectators, if any
+	if g.opts.ws != "" {
+		if _, err := serveSpectators(g.opts.ws, g.forest); err != nil {
+			return nil, fmt.Errorf("cannot start websocket server: %v", err)
+		}
+	}
+
+	// Seeds spent to plant trees, when the seed economy is on
+	if g.opts.config.SeedEconomy {
+		g.seedStock = newSeeds(g.opts.config.SeedStart, g.opts.config.SeedRegen, g.opts.config.SeedCost)
+	}
+
+	// Entries of the settings overlay, changing the live settings
+	g.settings = []setting{
+		{"Camera speed", func() string { return fmt.Sprintf("%.0f", g.camera.Speed) }, func(dir int) {
+			g.camera.Speed = math.Max(50, g.camera.Speed+50*float64(dir))
+		}},
+		{"Min zoom", func() string { return fmt.Sprintf("%.2f", g.camera.MinZoom) }, func(dir int) {
+			// Kept below the max zoom, as the config requires
+			if zoom := g.camera.MinZoom * math.Pow(1.1, float64(dir)); zoom < g.camera.MaxZoom {
+				g.camera.MinZoom = zoom
+			}
+			g.camera.TargetZoom = math.Max(g.camera.MinZoom, g.camera.TargetZoom)
+		}},
+		{"Max zoom", func() string { return fmt.Sprintf("%.2f", g.camera.MaxZoom) }, func(dir int) {
+			if zoom := g.camera.MaxZoom * math.Pow(1.1, float64(dir)); zoom > g.camera.MinZoom {
+				g.camera.MaxZoom = zoom
+			}
+			g.camera.TargetZoom = math.Min(g.camera.MaxZoom, g.camera.TargetZoom)
+		}},
+		{"Grid snap", func() string { return onOff(g.gridSnap) }, func(int) { g.gridSnap = !g.gridSnap }},
+		{"Wind sway", func() string { return onOff(g.windOn) }, func(int) { g.windOn = !g.windOn }},
+		{"Smooth sprites", func() string { return onOff(g.opts.config.Smooth) }, func(int) {
+			g.opts.config.Smooth = !g.opts.config.Smooth
+			g.setSmooth(g.opts.config.Smooth)
+		}},
+		{"Shadows", func() string { return fmt.Sprintf("%.0f%%", g.opts.config.ShadowOpacity*100) }, func(dir int) {
+			g.opts.config.ShadowOpacity = math.Max(0, math.Min(1, g.opts.config.ShadowOpacity+0.1*float64(dir)))
+		}},
+	}
+
+	// Generate a naturally spaced forest over the whole world
+	if g.opts.generate > 0 {
+		n := g.opts.generate
+		if r := g.room(); r >= 0 && n > r {
+			n = r
+		}
+		g.forest.Add(generateForest(g.rng, worldBounds, n, g.minSpacing, g.roll)...)
+		g.showStatus(fmt.Sprintf("Generated %d trees", g.forest.Count()), 3*time.Second)
+	}
+
+	// Rain or snow falling over the window, the config tells which at startup
+	g.weatherFx = &weather{}
+	g.weatherFx.kind, _ = weatherKind(g.opts.config.Weather)
+
+	// Tree density heatmap, shaded again after the forest changes
+	g.density = newHeatmap(g.opts.config.HeatmapCell)
+	g.forest.Subscribe(func(ForestEvent) { g.density.Invalidate() })
+
+	// Trees removed, or put back among the others by an undo, shift the indices of the others,
+	// so the selection is dropped, and so are the label being typed and the trees being dragged
+	g.forest.Subscribe(func(e ForestEvent) {
+		if e.Type == EventRemove || e.Type == EventClear || e.Shifted {
+			g.selected = map[int]bool{}
+			g.labeling = -1
+			g.movingTree, g.movingSelection = -1, false
+		}
+	})
+
+	// The trees matching the search are found again after the forest changes
+	g.forest.Subscribe(func(ForestEvent) { g.matchesDirty = true })
+
+	// Congratulate the milestones reached from now on, whoever planted the trees
+	g.unlocks = newAchievements(g.opts.config.Milestones, len(g.treesFrames), g.forest.Count())
+	g.forest.Subscribe(func(e ForestEvent) {
+		if e.Type != EventPlant {
+			return
+		}
+		for _, msg := range g.unlocks.Planted(e.Tree.Frame, g.forest.Count()) {
+			g.banners = append(g.banners, msg)
+			g.camera.Shake(6, 0.4)
+		}
+	})
+
+	// Texture filtering: smooth (linear) or crisp pixel-art (nearest-neighbor) sprites
+	g.setSmooth(g.opts.config.Smooth)
+
+	// Distance from a tree within which the cursor is over it
+	g.hoverRadius = g.opts.config.TreeScale * math.Max(g.treesFrames[0].W(), g.treesFrames[0].H()) / 2
+
+	g.start = time.Now()
+
+	// Benchmark planting trees at random positions, from an empty forest
+	if g.opts.benchmark > 0 {
+		g.bench = newBenchmark(g.opts.benchmark)
+	}
+
+	return g, nil
+}
+
+// HandleInput reacts to the keyboard and the mouse during a frame of dt seconds. Only the pause
+// menu and the settings overlay take input while they are open. Quitting sets quit.
+func (g *Game) HandleInput(win *pixelgl.Window, dt float64) {
+	g.camera.Bounds = win.Bounds()
+	g.cam = g.camera.Matrix()
+
+	// Minimap placement in the window
+	g.mini = newMinimap(worldBounds, win.Bounds(), g.opts.config.MinimapSize, 10, g.opts.config.MinimapCorner)
+	// Tree list placement in the window, kept scrolled within the trees
+	g.list = newTreeList(win.Bounds(), g.basicAtlas.LineHeight(), g.basicAtlas.Glyph('0').Advance, g.initialFontScale)
+	g.listScroll = g.list.clampScroll(g.listScroll, g.forest.Count())
+	// Palette placement in the window
+	g.palette = newPalette(win.Bounds(), len(g.treesFrames))
+
+	// Ctrl+Q to quit right away
+	if ctrlPressed(win) && win.JustPressed(g.keys["quit"]) {
+		g.quit = true
+		return
+	}
+
+	// Typing a tree label takes every key until Enter saves it or Escape cancels it
+	if g.labeling >= 0 {
+		g.labelText = editText(win, g.labelText)
+		if enterPressed(win) {
+			label := labelAction{index: g.labeling, from: g.forest.Trees[g.labeling].Label, to: strings.TrimSpace(g.labelText)}
+			label.Apply(g.forest)
+			g.record(label)
+			g.labeling = -1
+		} else if win.JustPressed(pixelgl.KeyEscape) {
+			g.labeling = -1
+		}
+		return
+	}
+
+	// Typing a search query highlights the matching trees as it is typed, until Enter goes to
+	// the first match or Escape clears the search
+	if g.searching {
+		g.search, g.matchesDirty = editText(win, g.search), true
+		if enterPressed(win) {
+			g.searching, g.nextMatch = false, 0
+			g.goToMatch()
+		} else if win.JustPressed(pixelgl.KeyEscape) {
+			g.searching, g.search = false, ""
+		}
+		return
+	}
+
+	// Escape to clear the selection, or else to pause the game with the pause menu, and
+	// again to resume
+	if win.JustPressed(g.keys["pause"]) && len(g.selected) > 0 && !g.paused {
+		g.selected = map[int]bool{}
+	} else if win.JustPressed(g.keys["pause"]) && g.search != "" && !g.paused {
+		g.search, g.matchesDirty = "", true
+	} else if win.JustPressed(g.keys["pause"]) {
+		g.paused = !g.paused
+		g.pauseSelected = pauseResume
+	}
+
+	// O to open or close the settings overlay, changed settings are saved to the config file
+	// when it closes
+	if win.JustPressed(g.keys["settings"]) && !g.paused {
+		g.settingsOn = !g.settingsOn
+		if !g.settingsOn && g.settingsChanged {
+			if err := g.saveSettings(); err != nil {
+				g.showStatus(fmt.Sprintf("Saving settings failed: %v", err), 3*time.Second)
+			} else {
+				g.showStatus("Settings saved to "+g.opts.configPath, 3*time.Second)
+			}
+		}
+		g.settingsChanged = false
+	}
+
+	// Settings overlay, the arrow keys select an entry and change its value
+	if g.settingsOn && !g.paused {
+		if win.JustPressed(g.keys["pan_up"]) {
+			g.settingSelected = (g.settingSelected + len(g.settings) - 1) % len(g.settings)
+		}
+		if win.JustPressed(g.keys["pan_down"]) {
+			g.settingSelected = (g.settingSelected + 1) % len(g.settings)
+		}
+		for _, change := range []struct {
+			key pixelgl.Button
+			dir int
+		}{{g.keys["pan_left"], -1}, {g.keys["pan_right"], 1}, {g.keys["zoom_out"], -1}, {g.keys["zoom_in"], 1}} {
+			if win.JustPressed(change.key) || win.Repeated(change.key) {
+				g.settings[g.settingSelected].adjust(change.dir)
+				g.settingsChanged = true
+			}
+		}
+	}
+
+	// Pause menu, driven by the arrow keys and Enter or by clicking its entries
+	if g.paused {
+		chosen := -1
+		if win.JustPressed(g.keys["pan_up"]) {
+			g.pauseSelected = (g.pauseSelected + len(pauseItems) - 1) % len(pauseItems)
+		}
+		if win.JustPressed(g.keys["pan_down"]) {
+			g.pauseSelected = (g.pauseSelected + 1) % len(pauseItems)
+		}
+		if enterPressed(win) {
+			chosen = g.pauseSelected
+		}
+		if win.JustPressed(g.keys["plant"]) {
+			for i, r := range pauseItemRects(win.Bounds(), g.basicAtlas.LineHeight(), g.initialFontScale) {
+				if r.Contains(win.MousePosition()) {
+					chosen = i
+				}
+			}
+		}
+		if chosen == pauseQuit {
+			g.quit = true
+			return
+		}
+		switch chosen {
+		case pauseResume:
+			g.paused = false
+		case pauseSave:
+			g.save()
+		}
+	}
+
+	// F11 to toggle fullscreen on the primary monitor
+	if win.JustPressed(g.keys["fullscreen"]) {
+		if win.Monitor() == nil {
+			win.SetMonitor(pixelgl.PrimaryMonitor())
+		} else {
+			win.SetMonitor(nil)
+		}
+	}
+
+	// H to hide or show the tutorial text
+	if win.JustPressed(g.keys["help"]) {
+		g.helpOn = !g.helpOn
+	}
+
+	// F12 to save a screenshot of the current view, once it is drawn
+	if win.JustPressed(g.keys["screenshot"]) {
+		g.screenshotDue = true
+	}
+
+	// F10 to save a map of the whole forest, whatever the view
+	if win.JustPressed(g.keys["map"]) {
+		g.saveMap()
+	}
+
+	// Game controls, ignored while paused or changing the settings
+	if !g.paused && !g.settingsOn {
+		// Mouse button left to plant tree, clicks coming too fast after the previous tree are ignored
+		// (clicking on the minimap moves the camera there instead, clicking a tree in the
+		// tree list glides the camera to it, and clicking a thumbnail of the palette selects
+		// its frame, or random again for the selected one)
+		if win.JustPressed(g.keys["plant"]) {
+			g.drag = nil
+			if g.listOn && g.list.screen.Contains(win.MousePosition()) {
+				if i, ok := g.list.indexAt(win.MousePosition(), g.listScroll, g.forest.Count()); ok {
+					g.gliding, g.touring = newGlide(g.camera.Pos, g.forest.Trees[i].Pos()), nil
+				}
+			} else if g.paletteOn && g.palette.screen.Contains(win.MousePosition()) {
+				if i, ok := g.palette.frameAt(win.MousePosition()); ok && i == g.brushFrame {
+					g.brushFrame = -1
+				} else if ok {
+					g.brushFrame = i
+				}
+			} else if g.minimapOn && g.mini.screen.Contains(win.MousePosition()) {
+				g.camera.Pos = g.mini.toWorld(win.MousePosition())
+			} else if ctrlPressed(win) {
+				mouse := g.cam.Unproject(win.MousePosition())
+				if i, ok := g.forest.Nearest(mouse, g.hoverRadius); ok && g.selected[i] {
+					g.movingSelection, g.moveLast = true, mouse
+				} else if ok {
+					g.movingTree = i
+				} else {
+					g.boxSelecting, g.boxStart = true, mouse
+				}
+			} else if shiftPressed(win) {
+				g.selectStart = g.cam.Unproject(win.MousePosition())
+				g.selecting = true
+			} else if g.erasing {
+				g.erasingDrag = true
+			} else if time.Since(g.lastPlantedByUser) >= g.plantCooldown {
+				g.paintLast = g.cam.Unproject(win.MousePosition())
+				g.painting = true
+				g.plantBrush(g.paintLast)
+			}
+		}
+
+		// Space to plant at the center of the view, for keyboard-only play (held down, it keeps
+		// planting at the key repeat rate, still limited by the cooldown)
+		if (win.JustPressed(g.keys["plant_center"]) || win.Repeated(g.keys["plant_center"])) && time.Since(g.lastPlantedByUser) >= g.plantCooldown {
+			g.plantTree(g.camera.Pos)
+		}
+
+		// Ctrl+drag a tree to move it, following the grid snap
+		if g.movingTree >= g.forest.Count() {
+			g.movingTree = -1 // Removed while dragged
+		}
+		if g.movingTree >= 0 {
+			pos := g.cam.Unproject(win.MousePosition())
+			if g.gridSnap {
+				pos = snapToGrid(pos, g.gridSize)
+			}
+			if from := g.forest.Trees[g.movingTree].Pos(); pos != from {
+				g.forest.Move(g.movingTree, pos)
+				g.moved([]int{g.movingTree}, []pixel.Vec{from})
+			}
+			if !win.Pressed(g.keys["plant"]) {
+				g.movingTree = -1
+			}
+		}
+
+		// Ctrl+drag a selected tree to move the whole selection
+		if g.movingSelection {
+			mouse := g.cam.Unproject(win.MousePosition())
+			if delta := mouse.Sub(g.moveLast); delta != pixel.ZV && len(g.selected) > 0 {
+				indices := sortedIndices(g.selected)
+				from := make([]pixel.Vec, len(indices))
+				for k, i := range indices {
+					from[k] = g.forest.Trees[i].Pos()
+				}
+				g.forest.MoveIndices(g.selected, delta)
+				g.moved(indices, from)
+			}
+			g.moveLast = mouse
+			if !win.Pressed(g.keys["plant"]) {
+				g.movingSelection = false
+			}
+		}
+
+		// Ctrl+drag from an empty spot to select the trees inside the rectangle when the
+		// button is released
+		if g.boxSelecting && win.JustReleased(g.keys["plant"]) {
+			g.boxSelecting = false
+			g.selected = g.forest.InRect(pixel.Rect{Min: g.boxStart, Max: g.cam.Unproject(win.MousePosition())}.Norm())
+			g.showStatus(fmt.Sprintf("Selected %d trees", len(g.selected)), 3*time.Second)
+		}
+
+		// Shift+drag to fill the rectangle with trees when the button is released
+		if g.selecting && win.JustReleased(g.keys["plant"]) {
+			g.selecting = false
+			filled := g.fillRect(pixel.Rect{Min: g.selectStart, Max: g.cam.Unproject(win.MousePosition())}.Norm())
+			g.showStatus(fmt.Sprintf("Filled %d trees", filled), 3*time.Second)
+		}
+
+		// Keep the eraser pressed to remove the trees under it, the batch is rebuilt once
+		// for all the trees removed at a time
+		if !win.Pressed(g.keys["plant"]) {
+			g.erasingDrag = false
+		}
+		if g.erasingDrag {
+			if near := g.forest.Near(g.cam.Unproject(win.MousePosition()), g.eraserRadius()); len(near) > 0 {
+				erased := newRemoveAction(g.forest.Trees, near)
+				erased.Apply(g.forest)
+				g.erased(erased)
+				g.showStatus(fmt.Sprintf("Erased %d trees", len(erased.trees)), time.Second)
+			}
+		}
+
+		// Keep dragging to paint trees along the path, spaced evenly so they don't overlap
+		if !win.Pressed(g.keys["plant"]) {
+			g.painting = false
+		}
+		if g.painting && g.opts.config.PaintSpacing > 0 {
+			mouse := g.cam.Unproject(win.MousePosition())
+			for g.paintLast.To(mouse).Len() >= g.opts.config.PaintSpacing {
+				g.paintLast = g.paintLast.Add(g.paintLast.To(mouse).Unit().Scaled(g.opts.config.PaintSpacing))
+				g.plantTree(g.paintLast)
+			}
+		}
+
+		// Ctrl+Z to undo the last change (a tree, a brush stroke, a fill, a paste, a deletion or a clear)
+		undo := ctrlPressed(win) && !shiftPressed(win) && win.JustPressed(g.keys["undo"])
+		if undo && len(g.undoStack) > 0 {
+			a := g.undoStack[len(g.undoStack)-1]
+			g.undoStack = g.undoStack[:len(g.undoStack)-1]
+			a.Revert(g.forest)
+			g.redoStack = append(g.redoStack, a)
+		}
+
+		// Ctrl+Y or Ctrl+Shift+Z to redo the last undone change
+		redo := ctrlPressed(win) && (win.JustPressed(g.keys["redo"]) || shiftPressed(win) && win.JustPressed(g.keys["undo"]))
+		if redo && len(g.redoStack) > 0 {
+			a := g.redoStack[len(g.redoStack)-1]
+			if p, ok := a.(plantAction); ok && g.room() >= 0 && len(p.trees) > g.room() {
+				g.showStatus("Forest full", time.Second)
+			} else {
+				g.redoStack = g.redoStack[:len(g.redoStack)-1]
+				a.Apply(g.forest)
+				g.undoStack = pushUndo(g.undoStack, a)
+			}
+		}
+
+		// Ctrl+R, while offered on startup, to replace the forest with the autosave
+		if ctrlPressed(win) && win.JustPressed(g.keys["recover"]) && time.Now().Before(g.recoverUntil) {
+			recovered, saved, err := loadForest(autosavePath)
+			if err != nil {
+				g.showStatus(fmt.Sprintf("Recovery failed: %v", err), 3*time.Second)
+			} else {
+				g.forest.Clear()
+				g.forest.Add(repairForest(recovered, len(g.treesFrames))...)
+				g.undoStack = g.undoStack[:0]
+				g.redoStack = g.redoStack[:0]
+				if saved != nil {
+					g.restoreCamera(saved)
+				}
+				g.showStatus(fmt.Sprintf("Recovered %d trees from %s", g.forest.Count(), autosavePath), 3*time.Second)
+			}
+			g.recoverUntil = time.Time{}
+		}
+
+		// Delete to remove the selected trees, or twice to clear the forest
+		if win.JustPressed(g.keys["clear"]) && len(g.selected) > 0 {
+			g.record(newRemoveAction(g.forest.Trees, g.selected))
+			removed := g.forest.RemoveIndices(g.selected)
+			g.showStatus(fmt.Sprintf("Deleted %d trees", len(removed)), 3*time.Second)
+		} else if win.JustPressed(g.keys["clear"]) {
+			if time.Now().Before(g.clearArmedUntil) {
+				g.showStatus(fmt.Sprintf("Cleared %d trees, Ctrl+%s to undo", g.forest.Count(), g.keys["undo"]), 3*time.Second)
+				g.record(clearAction{trees: append([]PlantedTree(nil), g.forest.Trees...)})
+				g.forest.Clear()
+				g.clearArmedUntil = time.Time{}
+			} else {
+				timeout := seconds(g.opts.config.ClearTimeout)
+				g.showStatus("Press Delete again to clear all trees", timeout)
+				g.clearArmedUntil = time.Now().Add(timeout)
+			}
+		}
+
+		// G to toggle grid-snap planting
+		if win.JustPressed(g.keys["grid_snap"]) {
+			g.gridSnap = !g.gridSnap
+		}
+
+		// R to toggle the random rotation of planted trees
+		if !ctrlPressed(win) && win.JustPressed(g.keys["rotation"]) {
+			g.rotateTrees = !g.rotateTrees
+		}
+
+		// U to toggle between random and uniform tree sizes
+		if win.JustPressed(g.keys["size"]) {
+			g.scaleTrees = !g.scaleTrees
+		}
+
+		// [ and ] to shrink or grow the brush, by one tree
+		if win.JustPressed(g.keys["brush_smaller"]) && g.brushSize > 1 {
+			g.brushSize--
+			g.showStatus(fmt.Sprintf("Brush: %d trees", g.brushSize), time.Second)
+		}
+		if win.JustPressed(g.keys["brush_larger"]) && g.brushSize < maxBrushSize {
+			g.brushSize++
+			g.showStatus(fmt.Sprintf("Brush: %d trees", g.brushSize), time.Second)
+		}
+
+		// C to toggle the eraser (Ctrl+C copies instead)
+		if !ctrlPressed(win) && win.JustPressed(g.keys["eraser"]) {
+			g.erasing = !g.erasing
+			if g.erasing {
+				g.showStatus("Eraser on", time.Second)
+			} else {
+				g.showStatus("Eraser off", time.Second)
+			}
+		}
+
+		// F to toggle mirroring planted trees horizontally
+		if win.JustPressed(g.keys["flip"]) {
+			g.flipTrees = !g.flipTrees
+		}
+
+		// P to pause the day/night cycle
+		if win.JustPressed(g.keys["pause_day"]) {
+			g.dayPaused = !g.dayPaused
+		}
+
+		// V to toggle the falling leaves
+		if !ctrlPressed(win) && win.JustPressed(g.keys["leaves"]) {
+			g.leavesOn = !g.leavesOn
+		}
+
+		// W to toggle the wind sway (redraws every tree each frame while on)
+		if win.JustPressed(g.keys["wind"]) {
+			g.windOn = !g.windOn
+		}
+
+		// B to switch between smooth and crisp pixel-art sprites
+		if win.JustPressed(g.keys["smooth"]) {
+			g.opts.config.Smooth = !g.opts.config.Smooth
+			win.SetSmooth(g.opts.config.Smooth)
+		}
+
+		// D to draw the trees by depth (lower ones in front) or in planting order
+		if win.JustPressed(g.keys["depth_sort"]) {
+			g.opts.config.DepthSort = !g.opts.config.DepthSort
+			g.forest.SetDepthSort(g.opts.config.DepthSort)
+		}
+
+		// F3 to toggle the frame time graph
+		if win.JustPressed(g.keys["frame_graph"]) {
+			g.graphOn = !g.graphOn
+		}
+
+		// J to toggle the tree density heatmap
+		if win.JustPressed(g.keys["heatmap"]) {
+			g.heatmapOn = !g.heatmapOn
+		}
+
+		// K to toggle the scale grid
+		if win.JustPressed(g.keys["grid"]) {
+			g.gridOn = !g.gridOn
+		}
+
+		// Tab to toggle the statistics panel
+		if win.JustPressed(g.keys["stats"]) {
+			g.statsOn = !g.statsOn
+		}
+
+		// F4 to change the weather, from clear to rain to snow
+		if win.JustPressed(g.keys["weather"]) {
+			g.weatherFx.kind = (g.weatherFx.kind + 1) % len(weatherNames)
+			g.showStatus("Weather: "+weatherNames[g.weatherFx.kind], time.Second)
+		}
+
+		// F5 to toggle the vignette
+		if win.JustPressed(g.keys["vignette"]) {
+			g.vignetteOn = !g.vignetteOn
+		}
+
+		// F2 to toggle the tree list
+		if win.JustPressed(g.keys["tree_list"]) {
+			g.listOn = !g.listOn
+		}
+
+		// F6 to toggle the palette
+		if win.JustPressed(g.keys["palette"]) {
+			g.paletteOn = !g.paletteOn
+		}
+
+		// X to toggle the world position readout of the cursor
+		if win.JustPressed(g.keys["coords"]) {
+			g.coordsOn = !g.coordsOn
+		}
+
+		// L to start or stop recording the planted trees
+		if win.JustPressed(g.keys["record"]) {
+			if g.rec == nil {
+				path := fmt.Sprintf("recording-%s.jsonl", time.Now().Format("20060102-150405"))
+				var err error
+				if g.rec, err = startRecording(path); err != nil {
+					g.showStatus(fmt.Sprintf("Recording failed: %v", err), 3*time.Second)
+				} else {
+					g.showStatus("Recording to "+path, 3*time.Second)
+				}
+			} else {
+				if err := g.rec.Close(); err != nil {
+					g.showStatus(fmt.Sprintf("Recording failed: %v", err), 3*time.Second)
+				} else {
+					g.showStatus("Recording saved", 3*time.Second)
+				}
+				g.rec = nil
+			}
+		}
+
+		// M to mute or unmute the sound, if there is any
+		if win.JustPressed(g.keys["mute"]) {
+			if g.snd == nil {
+				g.showStatus("No sound to mute (see -music and -plantsound)", 3*time.Second)
+			} else if g.snd.ToggleMute() {
+				g.showStatus("Sound muted", time.Second)
+			} else {
+				g.showStatus("Sound on", time.Second)
+			}
+		}
+
+		// N to toggle the minimap
+		if win.JustPressed(g.keys["minimap"]) {
+			g.minimapOn = !g.minimapOn
+		}
+
+		// Number keys 1-9 select a tree variety, 0 goes back to random
+		if win.JustPressed(pixelgl.Key0) {
+			g.brushFrame = -1
+		}
+		for i, key := range brushKeys {
+			if win.JustPressed(key) && i < len(g.treesFrames) {
+				g.brushFrame = i
+			}
+		}
+
+		// A to label the tree under the cursor, or else the last tree planted
+		if win.JustPressed(g.keys["label"]) && g.forest.Count() > 0 {
+			i, ok := g.forest.Nearest(g.cam.Unproject(win.MousePosition()), g.hoverRadius)
+			if !ok {
+				i = g.forest.Count() - 1
+			}
+			g.labeling, g.labelText = i, g.forest.Trees[i].Label
+		}
+
+		// / to search the trees by label or sprite, Enter to go to the next match
+		if win.JustPressed(g.keys["search"]) {
+			g.searching, g.search, g.matchesDirty = true, "", true
+		}
+		if win.JustPressed(g.keys["search_next"]) && g.search != "" {
+			g.goToMatch()
+		}
+
+		// Ctrl+S to save the forest
+		if ctrlPressed(win) && win.JustPressed(g.keys["save"]) {
+			g.save()
+		}
+
+		// Ctrl+E to export the forest to CSV
+		if ctrlPressed(win) && win.JustPressed(g.keys["export"]) {
+			if err := exportCSV(g.csvPath, g.forest.Trees); err != nil {
+				g.showStatus(fmt.Sprintf("Export failed: %v", err), 3*time.Second)
+			} else {
+				g.showStatus(fmt.Sprintf("Exported %d rows to %s", g.forest.Count(), g.csvPath), 3*time.Second)
+			}
+		}
+
+		// Ctrl+I to import trees from the CSV file into the forest
+		if ctrlPressed(win) && win.JustPressed(g.keys["import"]) {
+			imported, err := importCSV(g.csvPath)
+			if err != nil {
+				g.showStatus(fmt.Sprintf("Import failed: %v", err), 3*time.Second)
+			} else {
+				// Only the first trees are imported when they don't all fit
+				if r := g.room(); r >= 0 && len(imported) > r {
+					imported = imported[:r]
+				}
+				imported = repairForest(imported, len(g.treesFrames))
+				g.forest.Add(imported...)
+				if len(imported) > 0 {
+					g.record(plantAction{trees: imported})
+				}
+				g.showStatus(fmt.Sprintf("Imported %d trees from %s", len(imported), g.csvPath), 3*time.Second)
+			}
+		}
+
+		// Ctrl+C to copy the selected trees, Ctrl+V to paste them centered on the cursor
+		if ctrlPressed(win) && win.JustPressed(g.keys["copy"]) && len(g.selected) > 0 {
+			g.clipboard = copyTrees(g.forest.Trees, g.selected)
+			g.showStatus(fmt.Sprintf("Copied %d trees", len(g.clipboard)), 3*time.Second)
+		}
+		if ctrlPressed(win) && win.JustPressed(g.keys["paste"]) && len(g.clipboard) > 0 {
+			pasted := pasteTrees(g.clipboard, g.cam.Unproject(win.MousePosition()), time.Now())
+			// Only the first trees are pasted when they don't all fit
+			if r := g.room(); r >= 0 && len(pasted) > r {
+				pasted = pasted[:r]
+			}
+			g.forest.Add(pasted...)
+			if len(pasted) > 0 {
+				g.record(plantAction{trees: pasted})
+			}
+			g.showStatus(fmt.Sprintf("Pasted %d trees", len(pasted)), 3*time.Second)
+		}
+
+		// Q and E to rotate the view (Ctrl+Q quits and Ctrl+E exports instead), Backspace to
+		// straighten it again
+		if !ctrlPressed(win) && win.Pressed(g.keys["rotate_left"]) {
+			g.camera.Rotation += dt
+		}
+		if !ctrlPressed(win) && win.Pressed(g.keys["rotate_right"]) {
+			g.camera.Rotation -= dt
+		}
+		if win.JustPressed(g.keys["reset_rotation"]) {
+			g.camera.Rotation = 0
+		}
+
+		// Arrow keys to accelerate the camera, it slows down to a stop once they are released
+		var steer pixel.Vec
+		// Arrow key to move camera left
+		if win.Pressed(g.keys["pan_left"]) {
+			steer.X--
+		}
+		// Arrow key to move camera right
+		if win.Pressed(g.keys["pan_right"]) {
+			steer.X++
+		}
+		// Arrow key to move camera down
+		if win.Pressed(g.keys["pan_down"]) {
+			steer.Y--
+		}
+		// Arrow key to move camera up
+		if win.Pressed(g.keys["pan_up"]) {
+			steer.Y++
+		}
+		g.camera.Steer(g.camera.ScreenDir(steer), dt)
+
+		// T to tour the forest hands-free, moving the camera from cluster to cluster of trees
+		if win.JustPressed(g.keys["tour"]) {
+			if g.touring != nil {
+				g.touring = nil
+			} else if stops := tourStops(g.forest.Trees, tourCell, g.camera.Pos); len(stops) > 0 {
+				g.touring, g.gliding = newTour(stops, g.camera.Pos), nil
+				g.showStatus("Touring the forest, arrows to stop", 3*time.Second)
+			} else {
+				g.showStatus("No trees to tour", time.Second)
+			}
+		}
+		// Moving the camera by hand takes back control from the tour
+		if g.touring != nil && (steer != pixel.ZV || win.Pressed(g.keys["pan"])) {
+			g.touring = nil
+		}
+		if g.touring != nil {
+			g.camera.Velocity = pixel.ZV
+			g.camera.Pos = g.touring.Update(dt, g.opts.config.TourSpeed)
+		}
+		// The glide to a tree of the list stops the same way, or once it arrives
+		if g.gliding != nil && (steer != pixel.ZV || win.Pressed(g.keys["pan"])) {
+			g.gliding = nil
+		}
+		if g.gliding != nil {
+			var arrived bool
+			g.camera.Velocity = pixel.ZV
+			g.camera.Pos, arrived = g.gliding.Update(dt)
+			if arrived {
+				g.gliding = nil
+			}
+		}
+
+		// Middle mouse drag to pan the camera
+		if win.JustPressed(g.keys["pan"]) {
+			g.panLastMouse = win.MousePosition()
+		}
+		if win.Pressed(g.keys["pan"]) {
+			mouse := win.MousePosition()
+			delta := g.camera.ScreenDir(mouse.Sub(g.panLastMouse)).Scaled(-1 / g.camera.ZoomLevel)
+			g.camera.Pan(delta.X, delta.Y)
+			g.panLastMouse = mouse
+		}
+
+		// Adjust zoom level with mouse wheel, keeping the world point under the cursor in place
+		// (over the tree list, the wheel scrolls the list instead, 3 rows a step)
+		if g.listOn && g.list.screen.Contains(win.MousePosition()) {
+			g.listScroll = g.list.clampScroll(g.listScroll-int(win.MouseScroll().Y*3), g.forest.Count())
+		} else {
+			g.camera.ZoomAt(win.MouseScroll().Y, win.MousePosition())
+		}
+		// Zoom keys zoom on the center of the view, as fast as 5 scroll steps a second
+		if win.Pressed(g.keys["zoom_in"]) {
+			g.camera.Zoom(5 * dt)
+		}
+		if win.Pressed(g.keys["zoom_out"]) {
+			g.camera.Zoom(-5 * dt)
+		}
+		// Ease the zoom toward its target so that scrolling feels fluid
+		g.camera.Ease(dt)
+
+		// Keep the visible area inside the world bounds
+		g.camera.Clamp(worldBounds)
+	}
+}
+
+// Update advances the game by dt seconds: the benchmark, the trees planted by the other players
+// or replayed, the seeds, the day/night cycle and the effects. It doesn't need the window, only
+// the window bounds the camera was last given.
+func (g *Game) Update(dt float64) {
+	// Plant the benchmark trees a batch per frame, and stop once the whole forest was
+	// rendered for a while
+	if g.bench != nil {
+		n, done := g.bench.Frame(dt)
+		if done {
+			g.bench.Report(os.Stdout, framePacing(g.opts.config.VSync, g.opts.config.FPSCap))
+			g.quit = true
+			return
+		}
+		for i := 0; i < n; i++ {
+			pos := pixel.V(worldBounds.Min.X+g.rng.Float64()*worldBounds.W(), worldBounds.Min.Y+g.rng.Float64()*worldBounds.H())
+			frame, scale, rot := g.roll(pos)
+			g.forest.Plant(pos, frame, scale, rot, g.flipped())
+		}
+	}
+	if !g.paused {
+		g.camera.UpdateShake(dt)
+	}
+
+	// Plant the trees received from the other players, but the ones that can't be drawn or
+	// stand outside the world, and the ones past the tree limit
+	for g.sess != nil && len(g.sess.Incoming) > 0 {
+		remote := <-g.sess.Incoming
+		if !validRemoteTree(remote) || g.room() == 0 {
+			continue
+		}
+		remote.Planted = time.Now()
+		remote = repairForest([]PlantedTree{remote}, len(g.treesFrames))[0]
+		g.forest.Add(remote)
+	}
+
+	// Plant the trees of the replay as their time comes
+	if g.replaying != nil {
+		for _, tree := range g.replaying.Due(time.Now()) {
+			tree.Planted = time.Now()
+			tree = repairForest([]PlantedTree{tree}, len(g.treesFrames))[0]
+			g.forest.Add(tree)
+			g.lastPlantAt = tree.Planted
+		}
+		if g.replaying.Done() {
+			g.showStatus("Replay finished", 3*time.Second)
+			g.replaying = nil
+		}
+	}
+
+	// Regenerate the seeds
+	if !g.paused {
+		g.seedStock.Update(dt)
+	}
+
+	// Advance the day/night cycle
+	if g.opts.config.DayLength > 0 && !g.dayPaused && !g.paused {
+		g.timeOfDay = math.Mod(g.timeOfDay+dt/g.opts.config.DayLength, 1)
+	}
+
+	// Animate the falling leaves and the weather
+	if !g.paused {
+		g.leaves.Update(dt)
+		g.weatherFx.Update(dt, g.opts.config.WeatherDensity, g.opts.config.WeatherSpeed, g.camera.Bounds)
+	}
+
+	// Average the FPS over the latest frames, for the window title
+	g.frameTimeGraph.add(dt)
+	g.frameSum += dt - g.frameTimes[g.frameIndex]
+	g.frameTimes[g.frameIndex] = dt
+	g.frameIndex = (g.frameIndex + 1) % len(g.frameTimes)
+	if g.frameCount < len(g.frameTimes) {
+		g.frameCount++
+	}
+	// Autosave a copy of the trees in the background, so the game goes on while the file
+	// is written
+	select {
+	case <-g.autosaveTick:
+		if g.changedSinceSave && !g.autosaving {
+			trees, saved := append([]PlantedTree(nil), g.forest.Trees...), g.savedCamera()
+			g.autosaving, g.changedSinceSave = true, false
+			go func() { g.autosaved <- saveForest(autosavePath, trees, saved) }()
+		}
+	case err := <-g.autosaved:
+		g.autosaving = false
+		if err != nil {
+			g.showStatus(fmt.Sprintf("Auto-save failed: %v", err), 3*time.Second)
+		} else {
+			g.showStatus("Auto-saved", time.Second)
+		}
+	default:
+	}
+}
+
+// Draw renders the frame to the window: the world seen from the camera, then the HUD in screen
+// space.
+func (g *Game) Draw(win *pixelgl.Window) {
+	win.SetMatrix(g.cam)
+
+	// Calculate the position of the tree count label
+	countTxtPos := win.Bounds().Min.Add(pixel.V(5, win.Bounds().H()-25))
+	countTxtPos = g.cam.Unproject(countTxtPos)
+
+	// Rewrite the tree count label only when what it shows changed
+	shown := countLabel{trees: g.forest.Count(), brush: g.brushFrame, seeds: -1, recording: g.rec != nil, full: g.room() == 0}
+	if g.seedStock != nil {
+		shown.seeds = int(g.seedStock.count)
+	}
+	if g.search != "" {
+		shown.search, shown.matches = g.search, len(g.searchMatches())
+	}
+	if shown != g.countShown {
+		g.countShown = shown
+		g.treeCountLabel.Clear()
+		fmt.Fprintf(g.treeCountLabel, "Trees planted: %d", shown.trees)
+		if shown.brush < 0 {
+			fmt.Fprint(g.treeCountLabel, " | Brush: Random")
+		} else {
+			fmt.Fprintf(g.treeCountLabel, " | Brush: Tree %d", shown.brush+1)
+		}
+		if shown.recording {
+			fmt.Fprint(g.treeCountLabel, " | REC")
+		}
+		if shown.full {
+			fmt.Fprint(g.treeCountLabel, " | Forest full")
+		}
+		if shown.seeds >= 0 {
+			fmt.Fprintf(g.treeCountLabel, " | Seeds: %d", shown.seeds)
+		}
+		if shown.search != "" {
+			fmt.Fprintf(g.treeCountLabel, " | Found %d: %s", shown.matches, shown.search)
+		}
+	}
+
+	// Status label right below the tree count
+	statusTxtPos := g.cam.Unproject(win.Bounds().Min.Add(pixel.V(5, win.Bounds().H()-50)))
+	status := ""
+	if g.labeling >= 0 {
+		status = fmt.Sprintf("Label: %s_ (Enter to save, Escape to cancel)", g.labelText)
+	} else if g.searching {
+		status = fmt.Sprintf("Search: %s_ (Enter to go to the matches, Escape to clear)", g.search)
+	} else if time.Now().Before(g.statusUntil) {
+		status = g.statusMsg
+	}
+	// Rewritten only when it changed, like the tree count label
+	if status != g.statusShown {
+		g.statusShown = status
+		g.statusLabel.Clear()
+		fmt.Fprint(g.statusLabel, status)
+	}
+
+	// Visible world area
+	view := visibleArea(g.cam, win.Bounds())
+	darkness := 1 - daylight(g.timeOfDay)
+
+	// The world is drawn to the window, or to the canvas at a higher resolution when
+	// supersampling, sceneIM scaling window coordinates to it
+	var scene sceneTarget = win
+	sceneIM := pixel.IM
+	if g.canvas != nil {
+		if size := win.Bounds().Size().Scaled(g.opts.config.Supersample); g.canvas.Bounds().Size() != size {
+			g.canvas.SetBounds(pixel.Rect{Max: size})
+		}
+		scene, sceneIM = g.canvas, pixel.IM.Scaled(pixel.ZV, g.opts.config.Supersample)
+		scene.SetMatrix(g.cam.Chained(sceneIM))
+	}
+
+	// Set the background color from the grass color (or the gradient) at noon to dark
+	// blue-green at night
+	scene.Clear(lerpColor(g.grassColor, nightColor, darkness))
+	if g.gradientOn {
+		g.sky.Clear()
+		drawGradient(g.sky, win.Bounds(), lerpColor(g.gradientTop, nightColor, darkness), lerpColor(g.gradientBottom, nightColor, darkness))
+		scene.SetMatrix(sceneIM)
+		g.sky.Draw(scene)
+		scene.SetMatrix(g.cam.Chained(sceneIM))
+	}
+	// Tile the ground texture over the visible area, darkened at night like the trees
+	if g.ground != nil {
+		drawGround(g.ground, g.groundTexture, view)
+		g.ground.SetColorMask(lerpColor(pixel.Alpha(1), nightTint, darkness))
+		g.ground.Draw(scene)
+	}
+	// Draw the scale grid when shown, or else a faint grid while grid-snap is on
+	g.overlay.Clear()
+	if g.gridOn {
+		drawGridOverlay(g.overlay, view, g.gridSize, g.camera.ZoomLevel)
+	} else if g.gridSnap {
+		g.overlay.Color = pixel.RGB(1, 1, 1).Mul(pixel.Alpha(0.15))
+		drawGrid(g.overlay, view, g.gridSize, 1/g.camera.ZoomLevel)
+	}
+	g.overlay.Draw(scene)
+	// Redraw the trees every frame while they sway or a sapling is growing or popping in,
+	// otherwise only when the forest changed (or the animation stopped, to leave them at rest)
+	now := time.Now()
+	growing := now.Sub(g.lastPlantAt) < g.growDuration || now.Sub(g.lastPlantAt) < g.popDuration
+	// Trees are culled by their position, so the view is grown by the largest tree size
+	margin := g.opts.config.TreeScale * math.Max(g.opts.config.MaxTreeScale/defaultTreeScale, 1) * math.Max(g.treesFrames[0].W(), g.treesFrames[0].H())
+	cullView := pixel.R(view.Min.X-margin, view.Min.Y-margin, view.Max.X+margin, view.Max.Y+margin)
+	// Zoomed far out the sprites are tiny, each tree is drawn as a dot instead
+	lod := g.camera.ZoomLevel < g.opts.config.LODZoom
+	if g.paused || lod {
+		// Leave the trees frozen as they were last drawn
+	} else if g.windOn || growing {
+		g.forest.RebuildAnimated(g.batch, cullView, func(t PlantedTree) (int, pixel.Matrix) {
+			frame, local := t.Frame, pixel.IM
+			if progress := growthProgress(t.Planted, now, g.growDuration); progress < 1 {
+				if progress < 0.5 && g.opts.config.SaplingFrame >= 0 && g.opts.config.SaplingFrame < len(g.treesFrames) {
+					frame = g.opts.config.SaplingFrame
+				}
+				local = growthScale(g.treesFrames[frame], progress)
+			}
+			if progress := growthProgress(t.Planted, now, g.popDuration); progress < 1 {
+				local = local.Chained(popScale(g.treesFrames[frame], progress))
+			}
+			if g.windOn {
+				local = local.Chained(swayRotation(t, g.treesFrames[frame], now.Sub(g.start).Seconds(), g.opts.config.SwayAmplitude, g.opts.config.SwaySpeed))
+			}
+			return frame, local.Chained(t.Matrix())
+		})
+	} else {
+		g.forest.Sync(g.batch)
+	}
+	// Draw the shadows under the trees, fading away at night
+	if g.opts.config.ShadowOpacity > 0 && !lod {
+		g.shadows.Clear()
+		drawShadows(g.shadows, g.forest.Trees, g.treesFrames, cullView, g.opts.config.ShadowOpacity*daylight(g.timeOfDay), g.opts.config.ShadowOffset)
+		g.shadows.Draw(scene)
+	}
+	// Draws images in batch 1 (or the dots), darkened at night
+	tint := lerpColor(pixel.Alpha(1), nightTint, darkness)
+	if lod {
+		g.dots.Clear()
+		drawLOD(g.dots, g.forest.Trees, g.dotColors, view, g.opts.config.LODDotSize/g.camera.ZoomLevel, tint)
+		g.dots.Draw(scene)
+	} else {
+		g.batch.SetColorMask(tint)
+		g.batch.Draw(scene)
+	}
+	// Shade the world by tree density over the trees
+	if g.heatmapOn {
+		g.density.Update(g.forest.Trees)
+		g.density.imd.Draw(scene)
+	}
+	// Draw the falling leaves over the trees
+	g.effects.Clear()
+	g.leaves.Draw(g.effects)
+	// Outline the rectangle being filled or selected, and circle the selected trees
+	if g.selecting || g.boxSelecting {
+		g.effects.Color = pixel.RGB(1, 1, 1)
+		from := g.selectStart
+		if g.boxSelecting {
+			from = g.boxStart
+		}
+		g.effects.Push(from, g.cam.Unproject(win.MousePosition()))
+		g.effects.Rectangle(1 / g.camera.ZoomLevel)
+	}
+	// Outline the brush around the cursor, or the eraser in red
+	if g.erasing && !g.paused {
+		g.effects.Color = pixel.RGB(1, 0.25, 0.2).Mul(pixel.Alpha(0.8))
+		g.effects.Push(g.cam.Unproject(win.MousePosition()))
+		g.effects.Circle(g.eraserRadius(), 2/g.camera.ZoomLevel)
+	} else if g.brushSize > 1 && !g.paused {
+		g.effects.Color = pixel.RGB(1, 1, 1).Mul(pixel.Alpha(0.6))
+		g.effects.Push(g.cam.Unproject(win.MousePosition()))
+		g.effects.Circle(brushRadius(g.brushSize, g.opts.config.BrushSpread), 1/g.camera.ZoomLevel)
+	}
+	g.effects.Color = pixel.RGB(1, 0.85, 0.2)
+	for i := range g.selected {
+		g.effects.Push(g.forest.Trees[i].Pos())
+		g.effects.Circle(g.hoverRadius, 2/g.camera.ZoomLevel)
+	}
+	// Ring the trees matching the search
+	g.effects.Color = pixel.RGB(1, 0.35, 0.9)
+	for _, i := range g.searchMatches() {
+		g.effects.Push(g.forest.Trees[i].Pos())
+		g.effects.Circle(g.hoverRadius*1.25, 3/g.camera.ZoomLevel)
+	}
+	// Circle the tree being labeled
+	if g.labeling >= 0 {
+		g.effects.Color = pixel.RGB(0.5, 0.8, 1)
+		g.effects.Push(g.forest.Trees[g.labeling].Pos())
+		g.effects.Circle(g.hoverRadius, 2/g.camera.ZoomLevel)
+	}
+	g.effects.Draw(scene)
+	// Write the tree labels above the trees, unless zoomed too far out to read them
+	if g.camera.ZoomLevel >= g.opts.config.LabelZoom {
+		writeLabels(g.labelTxt, g.forest.Trees, g.treesFrames, view)
+		g.labelTxt.Draw(scene, pixel.IM.Scaled(pixel.ZV, labelScale))
+	}
+	// Downsample the supersampled world into the window
+	if g.canvas != nil {
+		win.SetMatrix(pixel.IM)
+		g.canvas.Draw(win, pixel.IM.Scaled(pixel.ZV, 1/g.opts.config.Supersample).Moved(win.Bounds().Center()))
+		win.SetMatrix(g.cam)
+	}
+	// Draw tuto text to screen, laid out relative to the current window size, unless hidden
+	if g.helpOn {
+		tutorialPos := g.homePos.Add(pixel.V(-win.Bounds().W()/6, win.Bounds().H()/9))
+		g.basicTxt.Draw(win, pixel.IM.Scaled(pixel.ZV, g.hudScale(2)).Moved(tutorialPos))
+	}
+
+	// Draw the treeCountLabel text at the corner of the view
+	g.treeCountLabel.Draw(win, pixel.IM.Scaled(pixel.ZV, g.hudScale(g.initialFontScale)/g.camera.ZoomLevel).Rotated(pixel.ZV, -g.camera.Rotation).Moved(countTxtPos))
+	// Draw the status text
+	g.statusLabel.Draw(win, pixel.IM.Scaled(pixel.ZV, g.initialFontScale/g.camera.ZoomLevel).Rotated(pixel.ZV, -g.camera.Rotation).Moved(statusTxtPos))
+
+	// Draw the vignette and the weather over the whole window in screen space, darkened
+	// at night like the trees, and the minimap over them
+	g.hud.Clear()
+	if g.vignetteOn {
+		drawVignette(g.hud, win.Bounds(), lerpColor(g.grassColor, nightColor, darkness), g.opts.config.VignetteStrength)
+	}
+	g.weatherFx.Draw(g.hud, tint)
+	if g.minimapOn {
+		g.mini.draw(g.hud, g.forest.Trees, view)
+	}
+
+	// Tooltip next to the cursor with the tree under it, on a dark background
+	g.tooltipTxt.Clear()
+	if i, ok := g.forest.Nearest(g.cam.Unproject(win.MousePosition()), g.hoverRadius); ok && !g.paused {
+		t := g.forest.Trees[i]
+		fmt.Fprintf(g.tooltipTxt, "Index: %d\nSprite: Tree %d\nX: %.0f Y: %.0f", i, t.Frame+1, t.X, t.Y)
+		if t.Label != "" {
+			fmt.Fprintf(g.tooltipTxt, "\nLabel: %s", t.Label)
+		}
+	}
+	tooltipMatrix := pixel.IM.Scaled(pixel.ZV, g.initialFontScale).Moved(win.MousePosition().Add(pixel.V(16, -16)))
+	if g.tooltipTxt.Bounds().Area() > 0 {
+		g.hud.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.7))
+		g.hud.Push(tooltipMatrix.Project(g.tooltipTxt.Bounds().Min).Sub(pixel.V(4, 4)), tooltipMatrix.Project(g.tooltipTxt.Bounds().Max).Add(pixel.V(4, 4)))
+		g.hud.Rectangle(0)
+	}
+	// Frame time graph in the bottom-left corner
+	if g.graphOn {
+		g.frameTimeGraph.draw(g.hud, pixel.R(10, 10, 10+g.opts.config.GraphWidth, 10+g.opts.config.GraphHeight))
+	}
+
+	// Tree list on the left, the row under the cursor highlighted
+	if g.listOn {
+		hovered := -1
+		if i, ok := g.list.indexAt(win.MousePosition(), g.listScroll, g.forest.Count()); ok {
+			hovered = i
+		}
+		g.list.draw(g.hud, g.listTxt, g.forest.Trees, g.listScroll, hovered)
+	}
+
+	// Palette on the right, the selected and hovered frames highlighted
+	if g.paletteOn {
+		hovered := -1
+		if i, ok := g.palette.frameAt(win.MousePosition()); ok {
+			hovered = i
+		}
+		g.palette.draw(g.hud, g.paletteBatch, g.frameSprites, g.brushFrame, hovered)
+	}
+
+	// Achievement banner at the top of the window, one message at a time
+	if now.After(g.bannerUntil) && len(g.banners) > 0 {
+		g.banner, g.banners = g.banners[0], g.banners[1:]
+		g.bannerUntil = now.Add(3 * time.Second)
+	}
+	g.bannerTxt.Clear()
+	bannerMatrix := pixel.IM
+	if now.Before(g.bannerUntil) {
+		fmt.Fprint(g.bannerTxt, g.banner)
+		scale := g.initialFontScale * 1.5
+		pos := pixel.V(win.Bounds().W()/2-g.bannerTxt.Bounds().W()*scale/2, win.Bounds().H()-80)
+		bannerMatrix = pixel.IM.Scaled(pixel.ZV, scale).Moved(pos)
+		g.hud.Color = pixel.RGB(0.31, 0.51, 0.15).Mul(pixel.Alpha(0.85))
+		g.hud.Push(bannerMatrix.Project(g.bannerTxt.Bounds().Min).Sub(pixel.V(12, 12)), bannerMatrix.Project(g.bannerTxt.Bounds().Max).Add(pixel.V(12, 12)))
+		g.hud.Rectangle(0)
+	}
+	win.SetMatrix(pixel.IM)
+	g.hud.Draw(win)
+	g.bannerTxt.Draw(win, bannerMatrix)
+	if g.paletteOn {
+		g.paletteBatch.Draw(win)
+	}
+	if g.listOn {
+		g.listTxt.Draw(win, pixel.IM.Scaled(pixel.ZV, g.initialFontScale))
+	}
+
+	// Draw the statistics panel in the top-right corner
+	if g.statsOn {
+		g.statsTxt.Clear()
+		writeStats(g.statsTxt, g.forest.Trees, len(g.treesFrames), g.plantTimes.perSecond(now, 5*time.Second), g.camera.ZoomLevel, g.opts.config.Smooth)
+		statsPos := win.Bounds().Max.Sub(pixel.V(g.statsTxt.Bounds().W()*g.initialFontScale+10, 30))
+		g.statsTxt.Draw(win, pixel.IM.Scaled(pixel.ZV, g.initialFontScale).Moved(statsPos))
+	}
+	g.tooltipTxt.Draw(win, tooltipMatrix)
+
+	// Draw the world position of the cursor at the bottom of the window, and where a tree
+	// would snap to
+	if g.coordsOn {
+		g.coordsTxt.Clear()
+		mouse := g.cam.Unproject(win.MousePosition())
+		fmt.Fprintf(g.coordsTxt, "X: %.0f Y: %.0f", mouse.X, mouse.Y)
+		if g.gridSnap {
+			snapped := snapToGrid(mouse, g.gridSize)
+			fmt.Fprintf(g.coordsTxt, " (snap: %.0f, %.0f)", snapped.X, snapped.Y)
+		}
+		coordsPos := pixel.V(win.Bounds().W()/2-g.coordsTxt.Bounds().W()*g.initialFontScale/2, 20)
+		g.coordsTxt.Draw(win, pixel.IM.Scaled(pixel.ZV, g.initialFontScale).Moved(coordsPos))
+	}
+
+	// Draw the settings overlay in the top-left corner, on a dark background
+	if g.settingsOn {
+		g.settingsTxt.Clear()
+		writeSettings(g.settingsTxt, g.settings, g.settingSelected)
+		fmt.Fprintf(g.settingsTxt, "\n%s/%s: Select, %s/%s: Change\n", g.keys["pan_up"], g.keys["pan_down"], g.keys["pan_left"], g.keys["pan_right"])
+		settingsMatrix := pixel.IM.Scaled(pixel.ZV, g.initialFontScale).Moved(pixel.V(20, win.Bounds().H()-100))
+		g.menu.Clear()
+		g.menu.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.7))
+		g.menu.Push(settingsMatrix.Project(g.settingsTxt.Bounds().Min).Sub(pixel.V(8, 8)), settingsMatrix.Project(g.settingsTxt.Bounds().Max).Add(pixel.V(8, 8)))
+		g.menu.Rectangle(0)
+		g.menu.Draw(win)
+		g.settingsTxt.Draw(win, settingsMatrix)
+	}
+
+	// Draw the pause menu over everything
+	if g.paused {
+		g.menu.Clear()
+		drawPauseMenu(g.menu, g.menuTxt, win.Bounds(), g.initialFontScale, g.pauseSelected)
+		g.menu.Draw(win)
+		g.menuTxt.Draw(win, pixel.IM.Scaled(pixel.ZV, g.initialFontScale))
+	}
+
+	// Save the screenshot asked for with F12
+	if g.screenshotDue {
+		g.screenshotDue = false
+		path := fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405"))
+		if err := saveScreenshot(path, win.Canvas()); err != nil {
+			g.showStatus(fmt.Sprintf("Screenshot failed: %v", err), 3*time.Second)
+		} else {
+			g.showStatus("Saved "+path, 3*time.Second)
+		}
+	}
+}
+
+// FPS returns the frame rate averaged over the latest frames (0 before the first one).
+func (g *Game) FPS() float64 {
+	if g.frameSum <= 0 {
+		return 0
+	}
+	return float64(g.frameCount) / g.frameSum
+}
+
+// Close finishes the recording left running, if any.
+func (g *Game) Close() error {
+	if g.rec == nil {
+		return nil
+	}
+	if err := g.rec.Close(); err != nil {
+		return fmt.Errorf("cannot save recording: %v", err)
+	}
+	return nil
+}
+
+// searchMatches returns the indices of the trees matching the search, found again only after
+// the forest or the search changed.
+func (g *Game) searchMatches() []int {
+	if g.matchesDirty {
+		g.matches, g.matchesDirty = findTrees(g.forest.Trees, g.search), false
+	}
+	return g.matches
+}
+
+// goToMatch glides the camera to the next tree matching the search, cycling through them in
+// planting order.
+func (g *Game) goToMatch() {
+	matches := g.searchMatches()
+	if len(matches) == 0 {
+		g.showStatus("No trees found", time.Second)
+		return
+	}
+	n := g.nextMatch % len(matches)
+	g.nextMatch = n + 1
+	g.gliding, g.touring = newGlide(g.camera.Pos, g.forest.Trees[matches[n]].Pos()), nil
+	g.showStatus(fmt.Sprintf("Match %d of %d", n+1, len(matches)), 2*time.Second)
+}
+
+// savedCamera returns the camera view to save with the forest
+func (g *Game) savedCamera() *SavedCamera {
+	return &SavedCamera{X: g.camera.Pos.X, Y: g.camera.Pos.Y, Zoom: g.camera.TargetZoom, Rotation: g.camera.Rotation}
+}
+
+// restoreCamera resumes the view saved with a forest
+func (g *Game) restoreCamera(saved *SavedCamera) {
+	g.camera.Pos, g.camera.Rotation = pixel.V(saved.X, saved.Y), saved.Rotation
+	g.camera.ZoomLevel = math.Max(g.camera.MinZoom, math.Min(g.camera.MaxZoom, saved.Zoom))
+	g.camera.TargetZoom = g.camera.ZoomLevel
+	g.camera.Clamp(worldBounds)
+}
+
+// showStatus displays a message under the tree count for a few seconds
+func (g *Game) showStatus(msg string, d time.Duration) {
+	g.statusMsg = msg
+	g.statusUntil = time.Now().Add(d)
+}
+
+// saveSettings writes the settings of the overlay to the config file. They are saved over the
+// file as it was loaded, so that the command-line overrides don't end up in it.
+func (g *Game) saveSettings() error {
+	cfg := g.opts.fileConfig
+	cfg.CamSpeed = g.camera.Speed
+	cfg.MinZoom = g.camera.MinZoom
+	cfg.MaxZoom = g.camera.MaxZoom
+	// The startup zoom has to stay within the limits for the file to load again
+	cfg.CamZoom = math.Max(cfg.MinZoom, math.Min(cfg.MaxZoom, cfg.CamZoom))
+	cfg.Smooth = g.opts.config.Smooth
+	cfg.ShadowOpacity = g.opts.config.ShadowOpacity
+	if err := saveConfig(g.opts.configPath, cfg); err != nil {
+		return err
+	}
+	g.opts.fileConfig = cfg
+	return nil
+}
+
+// room returns how many more trees can be planted before the forest is full (-1 when
+// there is no limit). Trees loaded or replayed are never refused.
+func (g *Game) room() int {
+	if g.opts.config.MaxTrees <= 0 {
+		return -1
+	}
+	if g.forest.Count() >= g.opts.config.MaxTrees {
+		return 0
+	}
+	return g.opts.config.MaxTrees - g.forest.Count()
+}
+
+// roll picks the frame, scale and rotation of a new tree at a world position from the brush
+// and the current rotation and size toggles.
+func (g *Game) roll(pos pixel.Vec) (int, float64, float64) {
+	return rollTree(g.rng, &g.opts.config, len(g.treesFrames), pos, g.brushFrame, g.rotateTrees, g.scaleTrees)
+}
+
+// flipped reports whether a new tree is mirrored: as set by the flip toggle, or at random
+// with random_flip while the random rotation is on.
+func (g *Game) flipped() bool {
+	if g.opts.config.RandomFlip && g.rotateTrees {
+		return g.rng.Intn(2) == 0
+	}
+	return g.flipTrees
+}
+
+// record puts a change just made on the undo stack. The undone changes can't be redone after it.
+func (g *Game) record(a action) {
+	g.undoStack = pushUndo(g.undoStack, a)
+	g.redoStack = g.redoStack[:0]
+}
+
+// dragging returns the action of the drag going on while it is the last one recorded, for the
+// next step of the drag to extend it. It returns nil otherwise.
+func (g *Game) dragging() action {
+	if g.drag != nil && len(g.undoStack) > 0 && g.undoStack[len(g.undoStack)-1] == g.drag {
+		return g.drag
+	}
+	return nil
+}
+
+// moved records trees just moved by a step of a drag, from the positions they had before the
+// step. The whole drag is undone at once.
+func (g *Game) moved(indices []int, from []pixel.Vec) {
+	to := make([]pixel.Vec, len(indices))
+	for k, i := range indices {
+		to[k] = g.forest.Trees[i].Pos()
+	}
+	if a, ok := g.dragging().(*moveAction); ok {
+		a.to = to
+		return
+	}
+	a := &moveAction{indices: indices, from: from, to: to}
+	g.record(a)
+	g.drag = a
+}
+
+// erased records trees just removed by a step of an eraser drag. The whole drag is undone at
+// once.
+func (g *Game) erased(a removeAction) {
+	if group, ok := g.dragging().(*groupAction); ok {
+		group.actions = append(group.actions, a)
+		return
+	}
+	group := &groupAction{actions: []action{a}}
+	g.record(group)
+	g.drag = group
+}
+
+// plantTree plants the selected tree (or a random one) at a world position,
+// following the grid-snap and spacing rules. It reports whether a tree was planted.
+func (g *Game) plantTree(pos pixel.Vec) bool {
+	tree, ok := g.plant(pos)
+	if ok {
+		g.record(plantAction{trees: []PlantedTree{tree}})
+	}
+	return ok
+}
+
+// plant plants a tree as plantTree does, leaving the undo history to the caller.
+func (g *Game) plant(pos pixel.Vec) (PlantedTree, bool) {
+	if g.gridSnap {
+		pos = snapToGrid(pos, g.gridSize)
+	}
+	// Reject trees planted too close to an existing one
+	if _, near := g.forest.Nearest(pos, g.minSpacing); g.minSpacing > 0 && near {
+		g.showStatus("Too close to another tree", time.Second)
+		return PlantedTree{}, false
+	}
+	if g.room() == 0 {
+		g.showStatus("Forest full", time.Second)
+		return PlantedTree{}, false
+	}
+	if !g.seedStock.Spend() {
+		g.showStatus("No seeds", time.Second)
+		return PlantedTree{}, false
+	}
+	frame, scale, rot := g.roll(pos)
+	tree := g.forest.Plant(pos, frame, scale, rot, g.flipped())
+	g.lastPlantAt = tree.Planted
+	g.lastPlantedByUser = tree.Planted
+	g.plantTimes.add(g.lastPlantAt)
+	if g.leavesOn {
+		g.leaves.Burst(tree.Pos(), 12)
+	}
+	g.snd.Plop()
+	if g.sess != nil {
+		g.sess.Send(tree)
+	}
+	return tree, true
+}
+
+// plantBrush plants the trees of the brush scattered around a world position, skipping the
+// spots too close to another tree. A brush of size 1 plants a single tree at the position.
+// The trees of a stroke are undone together.
+func (g *Game) plantBrush(pos pixel.Vec) {
+	if g.brushSize <= 1 {
+		g.plantTree(pos)
+		return
+	}
+	var stroke []PlantedTree
+	for _, offset := range brushOffsets(g.rng, g.brushSize, brushRadius(g.brushSize, g.opts.config.BrushSpread)) {
+		p := pos.Add(offset)
+		if g.gridSnap {
+			p = snapToGrid(p, g.gridSize)
+		}
+		if _, near := g.forest.Nearest(p, g.minSpacing); g.minSpacing > 0 && near {
+			continue
+		}
+		// Anything else stopping a tree (a full forest, no seeds) stops the rest too
+		tree, ok := g.plant(p)
+		if !ok {
+			break
+		}
+		stroke = append(stroke, tree)
+	}
+	if len(stroke) > 0 {
+		g.record(plantAction{trees: stroke})
+	}
+}
+
+// fillRect scatters trees uniformly inside a world rectangle, as many as the fill density
+// gives for its area, following the grid-snap and spacing rules (spots too close to another
+// tree are skipped), until the forest is full. It returns the number of trees planted, undone
+// together.
+func (g *Game) fillRect(r pixel.Rect) int {
+	n := int(r.Area() / (100 * 100) * g.opts.config.FillDensity)
+	var planted []PlantedTree
+	for i := 0; i < n && g.room() != 0; i++ {
+		pos := pixel.V(r.Min.X+g.rng.Float64()*r.W(), r.Min.Y+g.rng.Float64()*r.H())
+		if g.gridSnap {
+			pos = snapToGrid(pos, g.gridSize)
+		}
+		if _, near := g.forest.Nearest(pos, g.minSpacing); g.minSpacing > 0 && near {
+			continue
+		}
+		if !g.seedStock.Spend() {
+			g.showStatus("No seeds", time.Second)
+			break
+		}
+		frame, scale, rot := g.roll(pos)
+		tree := g.forest.Plant(pos, frame, scale, rot, g.flipped())
+		g.plantTimes.add(tree.Planted)
+		if g.sess != nil {
+			g.sess.Send(tree)
+		}
+		planted = append(planted, tree)
+	}
+	if len(planted) > 0 {
+		g.lastPlantAt = time.Now()
+		g.record(plantAction{trees: planted})
+		g.snd.Plop()
+		// The more trees at once, the bigger the thud
+		g.camera.Shake(math.Min(12, 2+float64(len(planted))/10), 0.3)
+	}
+	return len(planted)
+}
+
+// saveMap saves the forest map and tells how it went.
+func (g *Game) saveMap() {
+	path := fmt.Sprintf("map-%s.png", time.Now().Format("20060102-150405"))
+	ok, err := saveForestMap(path, g.forest, g.opts.config.MapSize, g.grassColor, g.groundTexture, g.opts.config.Smooth)
+	switch {
+	case !ok:
+		g.showStatus("No trees to map", time.Second)
+	case err != nil:
+		g.showStatus(fmt.Sprintf("Map failed: %v", err), 3*time.Second)
+	default:
+		g.showStatus(fmt.Sprintf("Saved the map of %d trees to %s", g.forest.Count(), path), 3*time.Second)
+	}
+}
+
+// hudScale returns the scale of HUD text drawn bitmap times as big as the bitmap font. The
+// -font font is loaded at the size it is drawn, so it isn't scaled.
+func (g *Game) hudScale(bitmap float64) float64 {
+	if g.hudFont {
+		return 1
+	}
+	return bitmap
+}
+
+// save saves the forest and tells how it went
+func (g *Game) save() {
+	if err := saveForest(savePath, g.forest.Trees, g.savedCamera()); err != nil {
+		g.showStatus(fmt.Sprintf("Save failed: %v", err), 3*time.Second)
+	} else {
+		g.showStatus(fmt.Sprintf("Saved %d trees", g.forest.Count()), 3*time.Second)
+	}
+}
+
+// eraserRadius returns the radius of the eraser, the brush radius but at least enough to
+// erase the tree under the cursor.
+func (g *Game) eraserRadius() float64 {
+	return math.Max(g.hoverRadius, brushRadius(g.brushSize, g.opts.config.BrushSpread))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+// testOptions returns the options of a game run from a temporary directory holding a
+// spritesheet of the given size cut into 32px frames, so that no save file of the repository
+// is loaded or written.
+func testOptions(t *testing.T, width, height int) options {
+	t.Helper()
+	dir := t.TempDir()
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			sheet.Set(x, y, color.RGBA{R: uint8(x * 4), G: 160, B: 40, A: 255})
+		}
+	}
+	file, err := os.Create(filepath.Join(dir, "trees.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, sheet); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	return options{
+		spritesheet: "trees.png",
+		frameSize:   32,
+		config:      defaultConfig(),
+		fileConfig:  defaultConfig(),
+		configPath:  "config.json",
+		keys:        defaultKeybindings(),
+		seed:        1,
+		fontSize:    defaultFontSize,
+	}
+}
+
+// testGame sets up a game without a window, with a spritesheet of two frames.
+func testGame(t *testing.T, change func(opts *options)) *Game {
+	t.Helper()
+	opts := testOptions(t, 64, 32)
+	if change != nil {
+		change(&opts)
+	}
+	g, err := newGame(opts, pixel.R(0, 0, 1024, 768), func(bool) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestUpdateAdvancesTheDay(t *testing.T) {
+	g := testGame(t, nil)
+	if len(g.treesFrames) != 2 {
+		t.Fatalf("%d frames, want 2", len(g.treesFrames))
+	}
+	start := g.timeOfDay
+	for i := 0; i < 60; i++ {
+		g.Update(0.5)
+	}
+	// 30 seconds of a 120 second day
+	if got := math.Mod(g.timeOfDay-start+1, 1); math.Abs(got-0.25) > 1e-9 {
+		t.Fatalf("day advanced by %v, want 0.25", got)
+	}
+	if fps := g.FPS(); math.Abs(fps-2) > 1e-9 {
+		t.Fatalf("FPS = %v, want 2", fps)
+	}
+	if g.quit {
+		t.Fatal("the game quit")
+	}
+}
+
+func TestUpdatePaused(t *testing.T) {
+	g := testGame(t, nil)
+	g.paused = true
+	start := g.timeOfDay
+	for i := 0; i < 10; i++ {
+		g.Update(1)
+	}
+	if g.timeOfDay != start {
+		t.Fatalf("time of day went from %v to %v while paused", start, g.timeOfDay)
+	}
+}
+
+func TestUpdateBenchmarkPlants(t *testing.T) {
+	g := testGame(t, func(opts *options) { opts.benchmark = 2*benchmarkPerFrame + 50 })
+	for frame, want := range []int{benchmarkPerFrame, 2 * benchmarkPerFrame, 2*benchmarkPerFrame + 50, 2*benchmarkPerFrame + 50} {
+		g.Update(1.0 / 60)
+		if g.forest.Count() != want {
+			t.Fatalf("frame %d: %d trees, want %d", frame, g.forest.Count(), want)
+		}
+	}
+	for _, tree := range g.forest.Trees {
+		if !worldBounds.Contains(tree.Pos()) || tree.Frame < 0 || tree.Frame >= len(g.treesFrames) {
+			t.Fatalf("tree %+v outside the world or of an unknown frame", tree)
+		}
+	}
+}
+
+func TestGeneratedForestIsReproducible(t *testing.T) {
+	generate := func(opts *options) { opts.generate = 50 }
+	a, b := testGame(t, generate), testGame(t, generate)
+	if a.forest.Count() == 0 {
+		t.Fatal("no trees generated")
+	}
+	strip := func(trees []PlantedTree) []PlantedTree {
+		out := append([]PlantedTree(nil), trees...)
+		for i := range out {
+			out[i].Planted = out[0].Planted
+		}
+		return out
+	}
+	if !reflect.DeepEqual(strip(a.forest.Trees), strip(b.forest.Trees)) {
+		t.Fatal("the same seed generated different forests")
+	}
+}
+
+func TestSpritesheetSmallerThanAFrame(t *testing.T) {
+	for _, size := range [][2]int{{16, 16}, {31, 64}, {64, 31}} {
+		opts := testOptions(t, size[0], size[1])
+		_, err := newGame(opts, pixel.R(0, 0, 1024, 768), func(bool) {})
+		if err == nil || !strings.Contains(err.Error(), "smaller than a 32px frame") {
+			t.Errorf("%dx%d spritesheet: error %v, want it smaller than a frame", size[0], size[1], err)
+		}
+	}
+}
+
+func TestSingleFrameSpritesheet(t *testing.T) {
+	opts := testOptions(t, 32, 32)
+	opts.generate = 50
+	opts.benchmark = benchmarkPerFrame
+	// A biome of frames the sheet doesn't have plants the only one
+	opts.config.Biomes = []Biome{{Name: "grove", Rect: [4]float64{-1e6, -1e6, 1e6, 1e6}, Frames: []int{3, 4}}}
+	g, err := newGame(opts, pixel.R(0, 0, 1024, 768), func(bool) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.treesFrames) != 1 {
+		t.Fatalf("%d frames, want 1", len(g.treesFrames))
+	}
+	g.Update(1.0 / 60)
+	if g.forest.Count() != 50+benchmarkPerFrame {
+		t.Fatalf("%d trees, want %d", g.forest.Count(), 50+benchmarkPerFrame)
+	}
+	for _, tree := range g.forest.Trees {
+		if tree.Frame != 0 {
+			t.Fatalf("tree %+v of a frame the sheet doesn't have", tree)
+		}
+	}
+}
+
+func TestSaveSettingsKeepsOverridesOut(t *testing.T) {
+	g := testGame(t, func(opts *options) {
+		// As given by -treescale, -fpscap, -zoom and -maxzoom
+		opts.config.TreeScale = 9
+		opts.config.FPSCap = 30
+		opts.config.CamZoom = 6
+		opts.config.MaxZoom = 8
+	})
+	g.camera.Speed = 750
+	g.camera.MaxZoom = 3
+	g.opts.config.Smooth = !g.opts.config.Smooth
+	g.opts.config.ShadowOpacity = 0.2
+	if err := g.saveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadConfig("config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := defaultConfig()
+	want.CamSpeed = 750
+	want.MaxZoom = 3
+	want.CamZoom = math.Min(want.CamZoom, 3)
+	want.Smooth = !want.Smooth
+	want.ShadowOpacity = 0.2
+	if !reflect.DeepEqual(saved, want) {
+		t.Fatalf("saved %+v, want %+v", saved, want)
+	}
+	if g.opts.config.TreeScale != 9 {
+		t.Fatalf("tree scale of the game = %v, want the override 9", g.opts.config.TreeScale)
+	}
+}
+
+func TestUpdateRemoteTrees(t *testing.T) {
+	g := testGame(t, func(opts *options) { opts.config.MaxTrees = 3 })
+	g.sess = &session{Incoming: make(chan PlantedTree, 8)}
+	for _, tree := range []PlantedTree{
+		{X: 10, Y: 10, Frame: 7},
+		{X: math.NaN(), Y: 10, Scale: 4},
+		{X: 20, Y: 10, Scale: math.Inf(1)},
+		{X: 1e9, Y: 10, Scale: 4},
+		{X: 30, Y: 10, Scale: 2, Rotation: math.NaN()},
+		{X: 40, Y: 10, Scale: 2},
+		{X: 50, Y: 10, Scale: 2},
+		{X: 60, Y: 10, Scale: 2},
+	} {
+		g.sess.Incoming <- tree
+	}
+	g.Update(1.0 / 60)
+
+	// A bad frame and a missing scale are repaired, the fourth valid tree finds the forest full
+	want := []PlantedTree{{X: 10, Y: 10, Frame: 1, Scale: defaultTreeScale}, {X: 40, Y: 10, Scale: 2}, {X: 50, Y: 10, Scale: 2}}
+	if len(g.forest.Trees) != len(want) {
+		t.Fatalf("planted %v, want %v", g.forest.Trees, want)
+	}
+	for i, tree := range g.forest.Trees {
+		tree.Planted = time.Time{}
+		if tree != want[i] {
+			t.Fatalf("tree %d = %+v, want %+v", i, tree, want[i])
+		}
+	}
+	if len(g.sess.Incoming) > 0 {
+		t.Fatalf("%d trees left unread", len(g.sess.Incoming))
+	}
+}
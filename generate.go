@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// poissonAttempts is the number of candidates tried around a sample before giving up on it
+// (Bridson's k).
+const poissonAttempts = 30
+
+// generateSpacing returns the distance kept between n generated trees so that they cover
+// bounds, and never less than minSpacing.
+func generateSpacing(bounds pixel.Rect, n int, minSpacing float64) float64 {
+	return math.Max(minSpacing, 0.75*math.Sqrt(bounds.Area()/float64(n)))
+}
+
+// rollTree picks the frame, scale and rotation of a new tree at a world position: the brush
+// frame, or else a random frame of the biome there (of any frame outside of biomes), the
+// configured tree scale, and a random rotation and size when they are turned on.
+func rollTree(rng *rand.Rand, cfg *Config, frameCount int, pos pixel.Vec, brush int, rotate, resize bool) (frame int, scale, rot float64) {
+	frame = brush
+	if frame < 0 {
+		if palette := biomeFrames(cfg.Biomes, pos, frameCount); len(palette) > 0 {
+			frame = palette[rng.Intn(len(palette))]
+		} else {
+			frame = rng.Intn(frameCount)
+		}
+	}
+	scale, rot = cfg.TreeScale, 0.0
+	if rotate {
+		rot = (rng.Float64()*2 - 1) * cfg.RotationJitter
+	}
+	// The random sizes are set for the default scale, so they follow the configured one
+	if resize {
+		scale *= (cfg.MinTreeScale + rng.Float64()*(cfg.MaxTreeScale-cfg.MinTreeScale)) / defaultTreeScale
+	}
+	return frame, scale, rot
+}
+
+// generateForest returns n naturally spaced trees covering bounds, at least minSpacing apart,
+// each picked by roll at its position.
+func generateForest(rng *rand.Rand, bounds pixel.Rect, n int, minSpacing float64, roll func(pos pixel.Vec) (int, float64, float64)) []PlantedTree {
+	spacing := generateSpacing(bounds, n, minSpacing)
+	var trees []PlantedTree
+	for _, pos := range poissonDisk(rng, bounds, spacing, n) {
+		frame, scale, rot := roll(pos)
+		trees = append(trees, PlantedTree{X: pos.X, Y: pos.Y, Frame: frame, Scale: scale, Rotation: rot})
+	}
+	return trees
+}
+
+// poissonDisk returns up to n points inside bounds, no two closer than spacing, using Bridson's
+// Poisson-disk sampling. The whole area is sampled and n points are picked from it, so they are
+// naturally spaced over all of bounds rather than clumped around the first sample.
+func poissonDisk(rng *rand.Rand, bounds pixel.Rect, spacing float64, n int) []pixel.Vec {
+	// Background grid with cells small enough to hold a single sample each
+	cell := spacing / math.Sqrt2
+	cols := int(math.Ceil(bounds.W() / cell))
+	rows := int(math.Ceil(bounds.H() / cell))
+	grid := make([]int, cols*rows) // Index+1 of the sample in each cell, 0 when empty
+
+	var points []pixel.Vec
+	var active []int // Samples that may still have room around them
+	cellOf := func(p pixel.Vec) (int, int) {
+		return int((p.X - bounds.Min.X) / cell), int((p.Y - bounds.Min.Y) / cell)
+	}
+	fits := func(p pixel.Vec) bool {
+		if p.X < bounds.Min.X || p.X >= bounds.Max.X || p.Y < bounds.Min.Y || p.Y >= bounds.Max.Y {
+			return false
+		}
+		cx, cy := cellOf(p)
+		for y := cy - 2; y <= cy+2; y++ {
+			for x := cx - 2; x <= cx+2; x++ {
+				if x < 0 || y < 0 || x >= cols || y >= rows {
+					continue
+				}
+				if i := grid[y*cols+x]; i > 0 && points[i-1].To(p).Len() < spacing {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	add := func(p pixel.Vec) {
+		points = append(points, p)
+		cx, cy := cellOf(p)
+		grid[cy*cols+cx] = len(points)
+		active = append(active, len(points)-1)
+	}
+
+	add(pixel.V(bounds.Min.X+rng.Float64()*bounds.W(), bounds.Min.Y+rng.Float64()*bounds.H()))
+	for len(active) > 0 {
+		i := rng.Intn(len(active))
+		p := points[active[i]]
+		found := false
+		for k := 0; k < poissonAttempts && !found; k++ {
+			q := p.Add(pixel.Unit(rng.Float64() * 2 * math.Pi).Scaled(spacing * (1 + rng.Float64())))
+			if fits(q) {
+				add(q)
+				found = true
+			}
+		}
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	rng.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+	if len(points) > n {
+		points = points[:n]
+	}
+	return points
+}
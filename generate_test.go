@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestPoissonDiskSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		bounds  pixel.Rect
+		spacing float64
+		n       int
+	}{
+		{"square", pixel.R(0, 0, 1000, 1000), 40, 10000},
+		{"off the origin", pixel.R(-3000, 500, -1000, 1500), 55, 10000},
+		{"narrow strip", pixel.R(0, 0, 2000, 30), 20, 10000},
+		{"smaller than the spacing", pixel.R(0, 0, 10, 10), 50, 10},
+		{"fewer than fit", pixel.R(0, 0, 1000, 1000), 20, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := poissonDisk(rand.New(rand.NewSource(1)), tt.bounds, tt.spacing, tt.n)
+			if len(points) == 0 || len(points) > tt.n {
+				t.Fatalf("%d points, want 1 to %d", len(points), tt.n)
+			}
+			for i, p := range points {
+				if !tt.bounds.Contains(p) {
+					t.Fatalf("point %v outside %v", p, tt.bounds)
+				}
+				for _, q := range points[:i] {
+					if d := p.To(q).Len(); d < tt.spacing {
+						t.Fatalf("points %v and %v only %v apart, want at least %v", p, q, d, tt.spacing)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateForestCount(t *testing.T) {
+	bounds := pixel.R(-2000, -2000, 2000, 2000)
+	roll := func(pixel.Vec) (int, float64, float64) { return 0, defaultTreeScale, 0 }
+	for _, n := range []int{1, 10, 500, 5000} {
+		trees := generateForest(rand.New(rand.NewSource(int64(n))), bounds, n, 8, roll)
+		if len(trees) != n {
+			t.Errorf("generated %d trees, want %d", len(trees), n)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// snapToGrid rounds a world position to the nearest multiple of the cell size.
+func snapToGrid(v pixel.Vec, cell float64) pixel.Vec {
+	return pixel.V(math.Round(v.X/cell)*cell, math.Round(v.Y/cell)*cell)
+}
+
+// drawGrid pushes the grid lines covering the visible world area into imd.
+func drawGrid(imd *imdraw.IMDraw, view pixel.Rect, cell, thickness float64) {
+	for x := math.Floor(view.Min.X/cell) * cell; x <= view.Max.X; x += cell {
+		imd.Push(pixel.V(x, view.Min.Y), pixel.V(x, view.Max.Y))
+		imd.Line(thickness)
+	}
+	for y := math.Floor(view.Min.Y/cell) * cell; y <= view.Max.Y; y += cell {
+		imd.Push(pixel.V(view.Min.X, y), pixel.V(view.Max.X, y))
+		imd.Line(thickness)
+	}
+}
+
+// gridMinSpacing is the smallest distance between the overlay grid lines on screen, in pixels.
+const gridMinSpacing = 16.0
+
+// drawGridOverlay pushes the lines of the scale grid covering view into imd, for a camera at
+// the given zoom. Zooming out doubles the cells whenever their lines would get closer than
+// gridMinSpacing on screen, and the lines fade as they get close to it. Lines are one pixel
+// thick at any zoom.
+func drawGridOverlay(imd *imdraw.IMDraw, view pixel.Rect, cell, zoom float64) {
+	for cell*zoom < gridMinSpacing {
+		cell *= 2
+	}
+	fade := math.Min(1, (cell*zoom-gridMinSpacing)/gridMinSpacing)
+	imd.Color = pixel.RGB(1, 1, 1).Mul(pixel.Alpha(0.1 + 0.15*fade))
+	drawGrid(imd, view, cell, 1/zoom)
+}
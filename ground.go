@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// groundScale is the draw scale of the ground texture, the same pixel size as the trees.
+const groundScale = defaultTreeScale
+
+// drawGround clears the batch and tiles the ground texture over the visible world area. Tiles
+// are aligned on the world origin, so the ground scrolls and zooms with the camera.
+func drawGround(batch *pixel.Batch, texture pixel.Picture, view pixel.Rect) {
+	batch.Clear()
+	tile := pixel.NewSprite(texture, texture.Bounds())
+	size := texture.Bounds().Size().Scaled(groundScale)
+	if size.X <= 0 || size.Y <= 0 {
+		return
+	}
+	for x := math.Floor(view.Min.X/size.X) * size.X; x < view.Max.X; x += size.X {
+		for y := math.Floor(view.Min.Y/size.Y) * size.Y; y < view.Max.Y; y += size.Y {
+			tile.Draw(batch, pixel.IM.Scaled(pixel.ZV, groundScale).Moved(pixel.V(x, y).Add(size.Scaled(0.5))))
+		}
+	}
+}
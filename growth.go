@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+// saplingScale is the fraction of its full size a tree has when just planted.
+const saplingScale = 0.25
+
+// popOvershoot is how much bigger than its size a tree is drawn right when planted, before
+// settling down.
+const popOvershoot = 1.2
+
+// growthProgress returns how far a tree planted at the given time has grown, from 0 to 1.
+// Trees without a plant time (loaded from a file) are fully grown.
+func growthProgress(planted, now time.Time, duration time.Duration) float64 {
+	if planted.IsZero() || duration <= 0 {
+		return 1
+	}
+	p := float64(now.Sub(planted)) / float64(duration)
+	if p >= 1 {
+		return 1
+	}
+	// Ease out so the tree shoots up and slows down near its full size
+	return 1 - (1-p)*(1-p)
+}
+
+// growthScale returns the scaling of a growing tree around the bottom of its frame.
+func growthScale(frame pixel.Rect, progress float64) pixel.Matrix {
+	return pixel.IM.Scaled(pixel.V(0, -frame.H()/2), saplingScale+(1-saplingScale)*progress)
+}
+
+// popScale returns the scaling of a tree popping in around the bottom of its frame, from the
+// overshoot down to its size as progress goes from 0 to 1.
+func popScale(frame pixel.Rect, progress float64) pixel.Matrix {
+	return pixel.IM.Scaled(pixel.V(0, -frame.H()/2), popOvershoot-(popOvershoot-1)*progress)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// runHeadless builds the forest without a window: a forest generated with -generate (like the
+// game would start with), or else the saved forest, and exports it to opts.out. It only needs
+// the spritesheet to know how many frames there are.
+func runHeadless(opts options) error {
+	spritesheet, err := loadPicture(opts.spritesheet)
+	if err != nil {
+		return fmt.Errorf("cannot load spritesheet: %v", err)
+	}
+	frameCount := len(cutFrames(spritesheet.Bounds(), float64(opts.frameSize)))
+	if frameCount == 0 {
+		return fmt.Errorf("spritesheet %s is smaller than a %dpx frame (choose another with -spritesheet or -framesize)", opts.spritesheet, opts.frameSize)
+	}
+
+	var trees []PlantedTree
+	if opts.generate > 0 {
+		rng := rand.New(rand.NewSource(opts.seed))
+		n := opts.generate
+		if opts.config.MaxTrees > 0 && n > opts.config.MaxTrees {
+			n = opts.config.MaxTrees
+		}
+		trees = generateForest(rng, worldBounds, n, opts.config.MinSpacing, func(pos pixel.Vec) (int, float64, float64) {
+			return rollTree(rng, &opts.config, frameCount, pos, -1, true, true)
+		})
+	} else {
+		saved, _, err := loadForest(savePath)
+		if err != nil {
+			return fmt.Errorf("cannot load %s: %v", savePath, err)
+		}
+		trees = repairForest(saved, frameCount)
+	}
+
+	if strings.EqualFold(filepath.Ext(opts.out), ".csv") {
+		err = exportCSV(opts.out, trees)
+	} else {
+		err = saveForest(opts.out, trees, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot export the forest: %v", err)
+	}
+	fmt.Printf("Exported %d trees to %s\n", len(trees), opts.out)
+	return nil
+}
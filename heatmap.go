@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// heatmap shades the world by tree density: cells of a grid go from cool to warm with the
+// number of trees in them. Its shapes are only rebuilt after the forest changed.
+type heatmap struct {
+	cell  float64        // Cell size in world units
+	imd   *imdraw.IMDraw // Shaded cells, drawn in world space
+	dirty bool           // Whether the forest changed since the cells were shaded
+}
+
+// newHeatmap returns a heatmap of cells of the given size, shaded on the first Update.
+func newHeatmap(cell float64) *heatmap {
+	return &heatmap{cell: cell, imd: imdraw.New(nil), dirty: true}
+}
+
+// Invalidate marks the heatmap to be shaded again on the next Update.
+func (h *heatmap) Invalidate() {
+	h.dirty = true
+}
+
+// Update shades the cells again from the trees if the forest changed since the last time.
+func (h *heatmap) Update(trees []PlantedTree) {
+	if !h.dirty {
+		return
+	}
+	h.dirty = false
+	counts := map[[2]int]int{}
+	most := 0
+	for _, t := range trees {
+		k := [2]int{int(math.Floor(t.X / h.cell)), int(math.Floor(t.Y / h.cell))}
+		counts[k]++
+		if counts[k] > most {
+			most = counts[k]
+		}
+	}
+	h.imd.Clear()
+	for k, n := range counts {
+		h.imd.Color = heatColor(float64(n) / float64(most)).Mul(pixel.Alpha(0.4))
+		min := pixel.V(float64(k[0])*h.cell, float64(k[1])*h.cell)
+		h.imd.Push(min, min.Add(pixel.V(h.cell, h.cell)))
+		h.imd.Rectangle(0)
+	}
+}
+
+// heatColor returns the color of a density from 0 to 1, from blue through yellow to red.
+func heatColor(t float64) pixel.RGBA {
+	blue, yellow, red := pixel.RGB(0, 0.3, 1), pixel.RGB(1, 1, 0), pixel.RGB(1, 0, 0)
+	if t < 0.5 {
+		return lerpColor(blue, yellow, t*2)
+	}
+	return lerpColor(yellow, red, t*2-1)
+}
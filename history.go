@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/faiface/pixel"
+)
+
+// maxUndo is the number of actions kept in the undo history.
+const maxUndo = 500
+
+// action is a change of the forest that can be undone and done again. Bulk changes (a fill,
+// a paste, a clear, a drag) are a single action, undone and redone at once. Every change made
+// by the player is recorded, so that each action finds the trees as it left them.
+type action interface {
+	// Apply makes the change, as when redone.
+	Apply(f *Forest)
+	// Revert undoes the change, leaving the forest as it was before.
+	Revert(f *Forest)
+}
+
+// plantAction plants trees: a single one, a brush stroke, a fill or a paste.
+type plantAction struct {
+	trees []PlantedTree
+}
+
+func (a plantAction) Apply(f *Forest) {
+	f.Add(a.trees...)
+}
+
+// Revert removes the trees last planted first, so each finds the one it added.
+func (a plantAction) Revert(f *Forest) {
+	for i := len(a.trees) - 1; i >= 0; i-- {
+		f.Remove(a.trees[i])
+	}
+}
+
+// removeAction removes trees from the forest, remembering the index each had so that undoing it
+// puts them back in place.
+type removeAction struct {
+	indices []int // Ascending
+	trees   []PlantedTree
+}
+
+// newRemoveAction returns the action of removing the trees at the given indices.
+func newRemoveAction(trees []PlantedTree, remove map[int]bool) removeAction {
+	var a removeAction
+	for _, i := range sortedIndices(remove) {
+		if i >= 0 && i < len(trees) {
+			a.indices = append(a.indices, i)
+			a.trees = append(a.trees, trees[i])
+		}
+	}
+	return a
+}
+
+func (a removeAction) Apply(f *Forest) {
+	remove := make(map[int]bool, len(a.indices))
+	for _, i := range a.indices {
+		remove[i] = true
+	}
+	f.RemoveIndices(remove)
+}
+
+// Revert inserts the trees back in ascending order of index, so each lands where it was.
+func (a removeAction) Revert(f *Forest) {
+	for k, i := range a.indices {
+		f.Insert(i, a.trees[k])
+	}
+}
+
+// clearAction clears the forest.
+type clearAction struct {
+	trees []PlantedTree
+}
+
+func (a clearAction) Apply(f *Forest) {
+	f.Clear()
+}
+
+func (a clearAction) Revert(f *Forest) {
+	f.Add(a.trees...)
+}
+
+// moveAction moves trees, from where they were to where they were dropped.
+type moveAction struct {
+	indices  []int
+	from, to []pixel.Vec
+}
+
+func (a *moveAction) Apply(f *Forest) {
+	f.Place(a.indices, a.to)
+}
+
+func (a *moveAction) Revert(f *Forest) {
+	f.Place(a.indices, a.from)
+}
+
+// labelAction changes the label of a tree.
+type labelAction struct {
+	index    int
+	from, to string
+}
+
+func (a labelAction) Apply(f *Forest) {
+	if a.index < f.Count() {
+		f.SetLabel(a.index, a.to)
+	}
+}
+
+func (a labelAction) Revert(f *Forest) {
+	if a.index < f.Count() {
+		f.SetLabel(a.index, a.from)
+	}
+}
+
+// groupAction is a sequence of actions, like the removals of an eraser drag, undone in reverse.
+type groupAction struct {
+	actions []action
+}
+
+func (a *groupAction) Apply(f *Forest) {
+	for _, step := range a.actions {
+		step.Apply(f)
+	}
+}
+
+func (a *groupAction) Revert(f *Forest) {
+	for i := len(a.actions) - 1; i >= 0; i-- {
+		a.actions[i].Revert(f)
+	}
+}
+
+// sortedIndices returns the indices of a set in ascending order.
+func sortedIndices(set map[int]bool) []int {
+	indices := make([]int, 0, len(set))
+	for i := range set {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// pushUndo records an action on the undo stack, dropping the oldest entry once the stack is full.
+func pushUndo(stack []action, a action) []action {
+	if len(stack) >= maxUndo {
+		stack = append(stack[:0], stack[1:]...)
+	}
+	return append(stack, a)
+}
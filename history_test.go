@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// testForest returns a forest of n trees in a row, each with a frame and label of its own.
+func testForest(n int) *Forest {
+	f := NewForest(pixel.R(-1000, -1000, 1000, 1000), nil, nil)
+	for i := 0; i < n; i++ {
+		f.Add(PlantedTree{X: float64(i * 10), Y: float64(-i * 5), Frame: i, Scale: 4, Label: fmt.Sprintf("tree %d", i)})
+	}
+	return f
+}
+
+// snapshot returns a copy of the trees of the forest.
+func snapshot(f *Forest) []PlantedTree {
+	return append([]PlantedTree(nil), f.Trees...)
+}
+
+// checkForest fails the test unless the forest holds exactly the trees want, in order, and
+// its spatial index finds each of them at its own index.
+func checkForest(t *testing.T, f *Forest, want []PlantedTree) {
+	t.Helper()
+	if !reflect.DeepEqual(snapshot(f), want) {
+		t.Fatalf("trees = %v, want %v", f.Trees, want)
+	}
+	for i, tree := range f.Trees {
+		if j, ok := f.Nearest(tree.Pos(), 1); !ok || j != i {
+			t.Fatalf("nearest tree to tree %d = %d, %v", i, j, ok)
+		}
+	}
+}
+
+func TestActionsRevertExactly(t *testing.T) {
+	tests := []struct {
+		name   string
+		action func(f *Forest) action // Action on the forest of 6 trees
+	}{
+		{"plant one", func(*Forest) action {
+			return plantAction{trees: []PlantedTree{{X: 100, Y: 100, Frame: 1, Scale: 4}}}
+		}},
+		{"plant many", func(*Forest) action {
+			return plantAction{trees: []PlantedTree{{X: 100, Y: 100}, {X: 200, Y: 100, Flip: true}, {X: 300, Y: 100, Rotation: 1}}}
+		}},
+		{"remove first and last", func(f *Forest) action {
+			return newRemoveAction(f.Trees, map[int]bool{0: true, 5: true})
+		}},
+		{"remove middle", func(f *Forest) action {
+			return newRemoveAction(f.Trees, map[int]bool{1: true, 3: true, 4: true})
+		}},
+		{"remove all", func(f *Forest) action {
+			return newRemoveAction(f.Trees, map[int]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true})
+		}},
+		{"clear", func(f *Forest) action {
+			return clearAction{trees: snapshot(f)}
+		}},
+		{"move", func(f *Forest) action {
+			return &moveAction{indices: []int{1, 4}, from: []pixel.Vec{f.Trees[1].Pos(), f.Trees[4].Pos()}, to: []pixel.Vec{pixel.V(500, 500), pixel.V(-500, 500)}}
+		}},
+		{"label", func(f *Forest) action {
+			return labelAction{index: 2, from: f.Trees[2].Label, to: "the old oak"}
+		}},
+		{"erase drag", func(f *Forest) action {
+			// The second removal is made on the forest left by the first one
+			first := newRemoveAction(f.Trees, map[int]bool{0: true, 2: true})
+			rest := append(append([]PlantedTree(nil), f.Trees[1]), f.Trees[3:]...)
+			return &groupAction{actions: []action{first, newRemoveAction(rest, map[int]bool{1: true})}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testForest(6)
+			before := snapshot(f)
+			a := tt.action(f)
+
+			a.Apply(f)
+			applied := snapshot(f)
+			if reflect.DeepEqual(applied, before) {
+				t.Fatal("Apply didn't change the forest")
+			}
+			a.Revert(f)
+			checkForest(t, f, before)
+
+			// Redone and undone again, it goes through the same states
+			a.Apply(f)
+			checkForest(t, f, applied)
+			a.Revert(f)
+			checkForest(t, f, before)
+		})
+	}
+}
+
+func TestUndoPlantAfterMoveAndLabel(t *testing.T) {
+	f := testForest(3)
+	before := snapshot(f)
+	var done []action
+	do := func(a action) {
+		a.Apply(f)
+		done = append(done, a)
+	}
+	do(plantAction{trees: []PlantedTree{{X: 50, Y: 50, Frame: 1, Scale: 4}}})
+	do(&moveAction{indices: []int{3}, from: []pixel.Vec{pixel.V(50, 50)}, to: []pixel.Vec{pixel.V(80, 20)}})
+	do(labelAction{index: 3, to: "oak"})
+	after := snapshot(f)
+
+	for i := len(done) - 1; i >= 0; i-- {
+		done[i].Revert(f)
+	}
+	checkForest(t, f, before)
+
+	// Redoing everything leaves a single moved and labeled tree, not a copy of it
+	for _, a := range done {
+		a.Apply(f)
+	}
+	checkForest(t, f, after)
+	if f.Count() != 4 {
+		t.Fatalf("count = %d, want 4", f.Count())
+	}
+}
+
+func TestInsertShiftsIndices(t *testing.T) {
+	f := testForest(4)
+	var events []ForestEvent
+	f.Subscribe(func(e ForestEvent) { events = append(events, e) })
+
+	removed := newRemoveAction(f.Trees, map[int]bool{1: true, 3: true})
+	removed.Apply(f)
+	events = nil
+	removed.Revert(f)
+	// Tree 1 goes back among the others, tree 3 back at the end
+	want := []bool{true, false}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Type != EventPlant || e.Shifted != want[i] {
+			t.Errorf("event %d = %s shifted %v, want plant shifted %v", i, e.Type, e.Shifted, want[i])
+		}
+	}
+}
+
+func TestPushUndoDropsOldest(t *testing.T) {
+	var stack []action
+	for i := 0; i < maxUndo+10; i++ {
+		stack = pushUndo(stack, labelAction{index: i})
+	}
+	if len(stack) != maxUndo {
+		t.Fatalf("len = %d, want %d", len(stack), maxUndo)
+	}
+	if first := stack[0].(labelAction).index; first != 10 {
+		t.Fatalf("oldest action = %d, want 10", first)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// Keybindings maps action names to the button triggering them.
+type Keybindings map[string]pixelgl.Button
+
+// defaultKeybindings returns the buttons of the actions unless keybindings.json changes them.
+// Save, export, import, copy, paste, undo, redo, recover and quit are used with Ctrl held
+// along with their key.
+func defaultKeybindings() Keybindings {
+	return Keybindings{
+		"plant":          pixelgl.MouseButtonLeft,
+		"pan":            pixelgl.MouseButtonMiddle,
+		"pan_up":         pixelgl.KeyUp,
+		"pan_down":       pixelgl.KeyDown,
+		"pan_left":       pixelgl.KeyLeft,
+		"pan_right":      pixelgl.KeyRight,
+		"zoom_in":        pixelgl.KeyEqual,
+		"zoom_out":       pixelgl.KeyMinus,
+		"rotate_left":    pixelgl.KeyQ,
+		"rotate_right":   pixelgl.KeyE,
+		"reset_rotation": pixelgl.KeyBackspace,
+		"plant_center":   pixelgl.KeySpace,
+		"pause":          pixelgl.KeyEscape,
+		"quit":           pixelgl.KeyQ,
+		"settings":       pixelgl.KeyO,
+		"fullscreen":     pixelgl.KeyF11,
+		"screenshot":     pixelgl.KeyF12,
+		"help":           pixelgl.KeyH,
+		"map":            pixelgl.KeyF10,
+		"grid_snap":      pixelgl.KeyG,
+		"grid":           pixelgl.KeyK,
+		"heatmap":        pixelgl.KeyJ,
+		"rotation":       pixelgl.KeyR,
+		"size":           pixelgl.KeyU,
+		"flip":           pixelgl.KeyF,
+		"brush_smaller":  pixelgl.KeyLeftBracket,
+		"brush_larger":   pixelgl.KeyRightBracket,
+		"eraser":         pixelgl.KeyC,
+		"pause_day":      pixelgl.KeyP,
+		"wind":           pixelgl.KeyW,
+		"leaves":         pixelgl.KeyV,
+		"stats":          pixelgl.KeyTab,
+		"coords":         pixelgl.KeyX,
+		"frame_graph":    pixelgl.KeyF3,
+		"tree_list":      pixelgl.KeyF2,
+		"palette":        pixelgl.KeyF6,
+		"weather":        pixelgl.KeyF4,
+		"vignette":       pixelgl.KeyF5,
+		"smooth":         pixelgl.KeyB,
+		"depth_sort":     pixelgl.KeyD,
+		"tour":           pixelgl.KeyT,
+		"label":          pixelgl.KeyA,
+		"search":         pixelgl.KeySlash,
+		"search_next":    pixelgl.KeyEnter,
+		"minimap":        pixelgl.KeyN,
+		"mute":           pixelgl.KeyM,
+		"record":         pixelgl.KeyL,
+		"clear":          pixelgl.KeyDelete,
+		"save":           pixelgl.KeyS,
+		"export":         pixelgl.KeyE,
+		"import":         pixelgl.KeyI,
+		"undo":           pixelgl.KeyZ,
+		"redo":           pixelgl.KeyY,
+		"recover":        pixelgl.KeyR,
+		"copy":           pixelgl.KeyC,
+		"paste":          pixelgl.KeyV,
+	}
+}
+
+// buttonsByName maps lowercase button names, as printed by pixelgl ("A", "Space", "F11",
+// "MouseButtonLeft"...), to their button.
+var buttonsByName = func() map[string]pixelgl.Button {
+	names := map[string]pixelgl.Button{}
+	for b := pixelgl.Button(0); b <= pixelgl.KeyLast; b++ {
+		if name := b.String(); name != "Invalid" {
+			names[strings.ToLower(name)] = b
+		}
+	}
+	return names
+}()
+
+// loadKeybindings reads a JSON object of action names to button names over the default
+// bindings. A missing file keeps the defaults. Unknown actions and button names are skipped,
+// and a file that can't be read keeps the defaults too, each with a warning.
+func loadKeybindings(path string) (Keybindings, []string) {
+	keys := defaultKeybindings()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return keys, []string{err.Error()}
+	}
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return keys, []string{fmt.Sprintf("%s: %v, using the default keybindings", path, err)}
+	}
+
+	// Sorted, so the warnings come in a stable order
+	actions := make([]string, 0, len(names))
+	for action := range names {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	var warnings []string
+	for _, action := range actions {
+		if _, ok := keys[action]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%s: unknown action %q", path, action))
+			continue
+		}
+		b, ok := buttonsByName[strings.ToLower(names[action])]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s: unknown key %q for %q, keeping %s", path, names[action], action, keys[action]))
+			continue
+		}
+		keys[action] = b
+	}
+	return keys, warnings
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/text"
+)
+
+// labelScale is the size of the tree labels, in world units per font pixel.
+const labelScale = 2.0
+
+// maxTypedLength is the longest label or search query that can be typed, in characters.
+const maxTypedLength = 32
+
+// appendTyped returns s with the typed text added, keeping only the characters the font has,
+// up to maxTypedLength.
+func appendTyped(s, typed string) string {
+	for _, r := range typed {
+		if r >= ' ' && r <= '~' && len(s) < maxTypedLength {
+			s += string(r)
+		}
+	}
+	return s
+}
+
+// writeLabels writes the labels of the trees inside view into txt, centered above their
+// sprite. txt is drawn in world space, scaled by labelScale.
+func writeLabels(txt *text.Text, trees []PlantedTree, frames []pixel.Rect, view pixel.Rect) {
+	txt.Clear()
+	for _, t := range trees {
+		if t.Label == "" || !view.Contains(t.Pos()) {
+			continue
+		}
+		top := t.Y + frames[t.Frame].H()*t.Scale/2
+		txt.Dot = pixel.V(t.X/labelScale-txt.BoundsOf(t.Label).W()/2, top/labelScale+2)
+		fmt.Fprint(txt, t.Label)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// lodFallbackColor is the dot color of frames whose pixels can't be read.
+var lodFallbackColor = pixel.RGB(0.1, 0.35, 0.05)
+
+// frameColors returns the average color of the opaque pixels of every frame, the color of the
+// dot standing for a tree of that frame when zoomed far out.
+func frameColors(spritesheet pixel.Picture, frames []pixel.Rect) []pixel.RGBA {
+	colors := make([]pixel.RGBA, len(frames))
+	pic, ok := spritesheet.(pixel.PictureColor)
+	for i, frame := range frames {
+		colors[i] = lodFallbackColor
+		if !ok {
+			continue
+		}
+		var sum pixel.RGBA
+		for x := frame.Min.X; x < frame.Max.X; x++ {
+			for y := frame.Min.Y; y < frame.Max.Y; y++ {
+				// Colors are premultiplied, so the sum is weighted by opacity
+				sum = sum.Add(pic.Color(pixel.V(x+0.5, y+0.5)))
+			}
+		}
+		if sum.A > 0 {
+			colors[i] = sum.Scaled(1 / sum.A)
+		}
+	}
+	return colors
+}
+
+// drawLOD draws every tree inside view as a square dot of the given world size, in the
+// color of its frame tinted by mask.
+func drawLOD(imd *imdraw.IMDraw, trees []PlantedTree, colors []pixel.RGBA, view pixel.Rect, size float64, mask pixel.RGBA) {
+	half := pixel.V(size/2, size/2)
+	for _, t := range trees {
+		if !view.Contains(t.Pos()) {
+			continue
+		}
+		imd.Color = colors[t.Frame].Mul(mask)
+		imd.Push(t.Pos().Sub(half), t.Pos().Add(half))
+		imd.Rectangle(0)
+	}
+}
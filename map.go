@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// maxMapSize caps the longest side of a forest map, in pixels, keeping the canvas within the
+// texture sizes graphics cards support.
+const maxMapSize = 8192
+
+// mapMargin is the room left around the trees of a forest map, in world units.
+const mapMargin = 32
+
+// maxMapZoom is the largest zoom a forest map is drawn at, so a small forest isn't blown up
+// to a huge blurry picture.
+const maxMapZoom = 4
+
+// mapBounds returns the world rectangle holding every tree of the forest, sprites included,
+// with a margin around. It reports false when there are no trees.
+func mapBounds(trees []PlantedTree, frames []pixel.Rect) (pixel.Rect, bool) {
+	if len(trees) == 0 {
+		return pixel.Rect{}, false
+	}
+	r := pixel.Rect{Min: trees[0].Pos(), Max: trees[0].Pos()}
+	for _, t := range trees {
+		half := frames[t.Frame].Size().Scaled(0.5)
+		m := t.Matrix()
+		for _, corner := range []pixel.Vec{pixel.V(-half.X, -half.Y), pixel.V(half.X, -half.Y), pixel.V(-half.X, half.Y), half} {
+			p := m.Project(corner)
+			r.Min = pixel.V(math.Min(r.Min.X, p.X), math.Min(r.Min.Y, p.Y))
+			r.Max = pixel.V(math.Max(r.Max.X, p.X), math.Max(r.Max.Y, p.Y))
+		}
+	}
+	return pixel.R(r.Min.X-mapMargin, r.Min.Y-mapMargin, r.Max.X+mapMargin, r.Max.Y+mapMargin), true
+}
+
+// mapFit returns the bounds of a canvas whose longest side is size pixels (less for a small
+// forest, see maxMapZoom) and the matrix drawing the world rectangle r onto it.
+func mapFit(r pixel.Rect, size int) (pixel.Rect, pixel.Matrix) {
+	zoom := math.Min(float64(size)/math.Max(r.W(), r.H()), maxMapZoom)
+	bounds := pixel.R(0, 0, math.Max(1, math.Ceil(r.W()*zoom)), math.Max(1, math.Ceil(r.H()*zoom)))
+	return bounds, pixel.IM.Moved(r.Center().Scaled(-1)).Scaled(pixel.ZV, zoom).Moved(bounds.Center())
+}
+
+// saveForestMap draws every tree of the forest, whatever the view, over the background color
+// (and the ground texture, if any) on an offscreen canvas, and writes it to a PNG file. It
+// reports false when there are no trees to draw.
+func saveForestMap(path string, f *Forest, size int, background pixel.RGBA, ground pixel.Picture, smooth bool) (bool, error) {
+	world, ok := mapBounds(f.Trees, f.frames)
+	if !ok {
+		return false, nil
+	}
+	bounds, m := mapFit(world, size)
+	canvas := pixelgl.NewCanvas(bounds)
+	canvas.SetSmooth(smooth)
+	canvas.Clear(background)
+	canvas.SetMatrix(m)
+	if ground != nil {
+		tiles := pixel.NewBatch(&pixel.TrianglesData{}, ground)
+		drawGround(tiles, ground, world)
+		tiles.Draw(canvas)
+	}
+	// A batch of its own, leaving the one drawn in the window as it is
+	batch := pixel.NewBatch(&pixel.TrianglesData{}, f.spritesheet)
+	for _, t := range f.drawOrder() {
+		f.Draw(batch, t)
+	}
+	batch.Draw(canvas)
+
+	img := canvasImage(canvas.Pixels(), int(bounds.W()), int(bounds.H()))
+	file, err := os.Create(path)
+	if err != nil {
+		return true, err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return true, err
+	}
+	return true, file.Close()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// minimap maps the whole world onto a small rectangle of the screen.
+type minimap struct {
+	world  pixel.Rect // World area shown in the minimap
+	screen pixel.Rect // Where the minimap is drawn, in screen coordinates
+}
+
+// newMinimap places a minimap of the given width in a corner of the window, keeping the
+// aspect ratio of the world.
+func newMinimap(world, window pixel.Rect, width, margin float64, corner string) minimap {
+	size := pixel.V(width, width*world.H()/world.W())
+	min := window.Min.Add(pixel.V(margin, margin))
+	switch corner {
+	case "top-left":
+		min.Y = window.Max.Y - margin - size.Y
+	case "top-right":
+		min = window.Max.Sub(size).Sub(pixel.V(margin, margin))
+	case "bottom-right":
+		min.X = window.Max.X - margin - size.X
+	}
+	return minimap{world: world, screen: pixel.Rect{Min: min, Max: min.Add(size)}}
+}
+
+// toScreen converts a world position to a position on the minimap.
+func (m minimap) toScreen(v pixel.Vec) pixel.Vec {
+	rel := v.Sub(m.world.Min)
+	return m.screen.Min.Add(pixel.V(rel.X*m.screen.W()/m.world.W(), rel.Y*m.screen.H()/m.world.H()))
+}
+
+// toWorld converts a position on the minimap back to a world position.
+func (m minimap) toWorld(v pixel.Vec) pixel.Vec {
+	rel := v.Sub(m.screen.Min)
+	return m.world.Min.Add(pixel.V(rel.X*m.world.W()/m.screen.W(), rel.Y*m.world.H()/m.screen.H()))
+}
+
+// draw pushes the minimap into imd: a dark background, a dot per tree and the outline of the
+// camera view. imd is expected to be drawn in screen space.
+func (m minimap) draw(imd *imdraw.IMDraw, trees []PlantedTree, view pixel.Rect) {
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.5))
+	imd.Push(m.screen.Min, m.screen.Max)
+	imd.Rectangle(0)
+
+	imd.Color = pixel.RGB(0.1, 0.35, 0.05)
+	for _, t := range trees {
+		p := m.toScreen(t.Pos())
+		if m.screen.Contains(p) {
+			imd.Push(p.Sub(pixel.V(1, 1)), p.Add(pixel.V(1, 1)))
+			imd.Rectangle(0)
+		}
+	}
+
+	imd.Color = pixel.RGB(1, 1, 1)
+	imd.Push(m.toScreen(view.Min), m.toScreen(view.Max))
+	imd.Rectangle(1)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sync"
+)
+
+// Plant events travel as a 4-byte big-endian length followed by the payload:
+// x, y, scale and rotation as float64 bits, the frame as an int32 and the flip as a byte.
+const plantEventSize = 4*8 + 4 + 1
+
+// writePlantEvent writes a length-prefixed plant event.
+func writePlantEvent(w io.Writer, t PlantedTree) error {
+	var buf [4 + plantEventSize]byte
+	binary.BigEndian.PutUint32(buf[0:], plantEventSize)
+	binary.BigEndian.PutUint64(buf[4:], math.Float64bits(t.X))
+	binary.BigEndian.PutUint64(buf[12:], math.Float64bits(t.Y))
+	binary.BigEndian.PutUint64(buf[20:], math.Float64bits(t.Scale))
+	binary.BigEndian.PutUint64(buf[28:], math.Float64bits(t.Rotation))
+	binary.BigEndian.PutUint32(buf[36:], uint32(int32(t.Frame)))
+	if t.Flip {
+		buf[40] = 1
+	}
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// readPlantEvent reads a length-prefixed plant event.
+func readPlantEvent(r io.Reader) (PlantedTree, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return PlantedTree{}, err
+	}
+	if n := binary.BigEndian.Uint32(size[:]); n != plantEventSize {
+		return PlantedTree{}, fmt.Errorf("unexpected event size %d", n)
+	}
+	var buf [plantEventSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return PlantedTree{}, err
+	}
+	return PlantedTree{
+		X:        math.Float64frombits(binary.BigEndian.Uint64(buf[0:])),
+		Y:        math.Float64frombits(binary.BigEndian.Uint64(buf[8:])),
+		Scale:    math.Float64frombits(binary.BigEndian.Uint64(buf[16:])),
+		Rotation: math.Float64frombits(binary.BigEndian.Uint64(buf[24:])),
+		Frame:    int(int32(binary.BigEndian.Uint32(buf[32:]))),
+		Flip:     buf[36] != 0,
+	}, nil
+}
+
+// validRemoteTree reports whether a tree received from another player can be planted: its
+// position, scale and rotation are numbers, and it stands inside the world.
+func validRemoteTree(t PlantedTree) bool {
+	for _, v := range []float64{t.X, t.Y, t.Scale, t.Rotation} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return worldBounds.Contains(t.Pos())
+}
+
+// session shares planted trees with other players over TCP. The server relays every tree it
+// receives to its other clients, a client only talks to its server.
+type session struct {
+	Incoming chan PlantedTree // Trees planted by the other players, applied by the game loop
+
+	relay bool
+	mu    sync.Mutex
+	peers map[net.Conn]chan PlantedTree // Outgoing queue of each connection
+}
+
+// serve starts a session accepting players on addr.
+func serve(addr string) (*session, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := newSession(true)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "trees: accept: %v\n", err)
+				return
+			}
+			s.add(conn)
+		}
+	}()
+	return s, nil
+}
+
+// connect joins the session served on addr.
+func connect(addr string) (*session, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := newSession(false)
+	s.add(conn)
+	return s, nil
+}
+
+func newSession(relay bool) *session {
+	return &session{
+		Incoming: make(chan PlantedTree, 256),
+		relay:    relay,
+		peers:    map[net.Conn]chan PlantedTree{},
+	}
+}
+
+// Send shares a tree planted locally with the other players.
+func (s *session) Send(t PlantedTree) {
+	s.broadcast(t, nil)
+}
+
+// broadcast queues the tree for every connection but from. Connections too slow to keep up
+// are dropped rather than blocking the game.
+func (s *session) broadcast(t PlantedTree, from net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn, out := range s.peers {
+		if conn == from {
+			continue
+		}
+		select {
+		case out <- t:
+		default:
+			s.drop(conn)
+		}
+	}
+}
+
+// add starts reading from and writing to a new connection.
+func (s *session) add(conn net.Conn) {
+	out := make(chan PlantedTree, 256)
+	s.mu.Lock()
+	s.peers[conn] = out
+	s.mu.Unlock()
+
+	// Writer
+	go func() {
+		w := bufio.NewWriter(conn)
+		for t := range out {
+			if err := writePlantEvent(w, t); err != nil {
+				break
+			}
+			if len(out) == 0 && w.Flush() != nil {
+				break
+			}
+		}
+		conn.Close()
+	}()
+
+	// Reader
+	go func() {
+		r := bufio.NewReader(conn)
+		for {
+			t, err := readPlantEvent(r)
+			if err != nil {
+				s.mu.Lock()
+				s.drop(conn)
+				s.mu.Unlock()
+				return
+			}
+			s.Incoming <- t
+			if s.relay {
+				s.broadcast(t, conn)
+			}
+		}
+	}()
+}
+
+// drop forgets a connection and closes it. s.mu must be held.
+func (s *session) drop(conn net.Conn) {
+	if out, ok := s.peers[conn]; ok {
+		delete(s.peers, conn)
+		close(out)
+		conn.Close()
+	}
+}
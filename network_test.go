@@ -0,0 +1,34 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidRemoteTree(t *testing.T) {
+	inf, nan := math.Inf(1), math.NaN()
+	tests := []struct {
+		name string
+		tree PlantedTree
+		ok   bool
+	}{
+		{"planted", PlantedTree{X: 10, Y: -20, Scale: 4, Rotation: 0.2}, true},
+		{"missing scale", PlantedTree{X: 10, Y: -20}, true},
+		{"world corner", PlantedTree{X: worldBounds.Min.X, Y: worldBounds.Min.Y, Scale: 4}, true},
+		{"outside the world", PlantedTree{X: worldBounds.Max.X + 1, Y: 0, Scale: 4}, false},
+		{"far away", PlantedTree{X: 0, Y: -1e12, Scale: 4}, false},
+		{"NaN x", PlantedTree{X: nan, Scale: 4}, false},
+		{"infinite y", PlantedTree{Y: -inf, Scale: 4}, false},
+		{"NaN scale", PlantedTree{Scale: nan}, false},
+		{"infinite scale", PlantedTree{Scale: inf}, false},
+		{"NaN rotation", PlantedTree{Scale: 4, Rotation: nan}, false},
+		{"infinite rotation", PlantedTree{Scale: 4, Rotation: inf}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok := validRemoteTree(tt.tree); ok != tt.ok {
+				t.Fatalf("validRemoteTree(%+v) = %v, want %v", tt.tree, ok, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// paletteCell is the size of a thumbnail of the palette panel, in screen pixels.
+const paletteCell = 56.0
+
+// palette places the side panel of tree frame thumbnails on the right of the window, filled
+// row by row in as many columns as the frames need to fit.
+type palette struct {
+	screen  pixel.Rect // Where the panel is drawn, in screen coordinates
+	columns int        // Thumbnails per row
+	count   int        // Number of frames
+}
+
+// newPalette places the palette of count frames in a window, its top right corner level with
+// the tree list, and the frames wrapped into more columns when they don't fit in one above the
+// minimap (in its default corner).
+func newPalette(window pixel.Rect, count int) palette {
+	rows := int((window.H() - 120 - 230 - 16) / paletteCell)
+	if rows < 1 {
+		rows = 1
+	}
+	if count < rows {
+		rows = count
+	}
+	columns := (count + rows - 1) / rows
+	rows = (count + columns - 1) / columns
+	size := pixel.V(float64(columns)*paletteCell+16, float64(rows)*paletteCell+16)
+	max := pixel.V(window.Max.X-10, window.Max.Y-120)
+	return palette{screen: pixel.Rect{Min: max.Sub(size), Max: max}, columns: columns, count: count}
+}
+
+// cell returns where the thumbnail of frame i is drawn, in screen coordinates.
+func (p palette) cell(i int) pixel.Rect {
+	col, row := i%p.columns, i/p.columns
+	min := pixel.V(p.screen.Min.X+8+float64(col)*paletteCell, p.screen.Max.Y-8-float64(row+1)*paletteCell)
+	return pixel.Rect{Min: min, Max: min.Add(pixel.V(paletteCell, paletteCell))}
+}
+
+// frameAt returns the frame whose thumbnail is under a screen position. It reports false
+// outside of the thumbnails.
+func (p palette) frameAt(v pixel.Vec) (int, bool) {
+	for i := 0; i < p.count; i++ {
+		if p.cell(i).Contains(v) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// draw draws the panel background and the cells of the selected and hovered frames (-1 for
+// none) into imd, and the thumbnails of the frames into batch, each fitted to its cell.
+func (p palette) draw(imd *imdraw.IMDraw, batch *pixel.Batch, sprites []*pixel.Sprite, selected, hovered int) {
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.7))
+	imd.Push(p.screen.Min, p.screen.Max)
+	imd.Rectangle(0)
+
+	batch.Clear()
+	for i, sprite := range sprites {
+		cell := p.cell(i)
+		switch i {
+		case selected:
+			imd.Color = pixel.RGB(0.31, 0.51, 0.15)
+		case hovered:
+			imd.Color = pixel.RGB(1, 1, 1).Mul(pixel.Alpha(0.2))
+		}
+		if i == selected || i == hovered {
+			imd.Push(cell.Min.Add(pixel.V(2, 2)), cell.Max.Sub(pixel.V(2, 2)))
+			imd.Rectangle(0)
+		}
+		frame := sprite.Frame()
+		scale := (paletteCell - 12) / frame.W()
+		if frame.H() > frame.W() {
+			scale = (paletteCell - 12) / frame.H()
+		}
+		sprite.Draw(batch, pixel.IM.Scaled(pixel.ZV, scale).Moved(cell.Center()))
+	}
+}
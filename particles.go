@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+const (
+	maxParticles    = 512 // Particles alive at once, bursts beyond are cut short
+	leafGravity     = 300 // Downward acceleration of the leaves, in world units per second squared
+	leafSize        = 3   // Side of a leaf square, in world units
+	leafMinLifetime = 0.8 // Shortest life of a leaf, in seconds
+	leafMaxLifetime = 1.6 // Longest life of a leaf, in seconds
+)
+
+// leafColors are the colors the falling leaves are picked from.
+var leafColors = []pixel.RGBA{
+	pixel.RGB(0.24, 0.55, 0.16),
+	pixel.RGB(0.42, 0.68, 0.22),
+	pixel.RGB(0.60, 0.52, 0.18),
+}
+
+// particle is a single leaf falling from a freshly planted tree.
+type particle struct {
+	pos, vel pixel.Vec  // Position and velocity in world units
+	life     float64    // Seconds left to live
+	lifetime float64    // Seconds it lived for in total
+	color    pixel.RGBA // Color, faded out as the particle dies
+}
+
+// particles is a small particle system for the leaves falling when trees are planted.
+type particles struct {
+	list []particle
+}
+
+// Burst spawns n leaves at pos, flying up and out before falling. The randomness doesn't
+// come from the planting random source, so the effect doesn't change a seeded forest.
+func (p *particles) Burst(pos pixel.Vec, n int) {
+	for i := 0; i < n && len(p.list) < maxParticles; i++ {
+		life := leafMinLifetime + rand.Float64()*(leafMaxLifetime-leafMinLifetime)
+		p.list = append(p.list, particle{
+			pos:      pos.Add(pixel.V(rand.Float64()*40-20, rand.Float64()*40-20)),
+			vel:      pixel.V(rand.Float64()*120-60, 60+rand.Float64()*100),
+			life:     life,
+			lifetime: life,
+			color:    leafColors[rand.Intn(len(leafColors))],
+		})
+	}
+}
+
+// Update moves the particles by dt seconds and removes the dead ones.
+func (p *particles) Update(dt float64) {
+	alive := p.list[:0]
+	for _, q := range p.list {
+		q.life -= dt
+		if q.life <= 0 {
+			continue
+		}
+		q.vel.Y -= leafGravity * dt
+		q.vel.X *= 1 - 2*dt // Air drag, so leaves drift down rather than fly away
+		q.pos = q.pos.Add(q.vel.Scaled(dt))
+		alive = append(alive, q)
+	}
+	p.list = alive
+}
+
+// Draw draws the particles as small squares fading out with age.
+func (p *particles) Draw(imd *imdraw.IMDraw) {
+	for _, q := range p.list {
+		imd.Color = q.color.Mul(pixel.Alpha(q.life / q.lifetime))
+		imd.Push(q.pos, q.pos.Add(pixel.V(leafSize, leafSize)))
+		imd.Rectangle(0)
+	}
+}
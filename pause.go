@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+)
+
+// Entries of the pause menu.
+const (
+	pauseResume = iota
+	pauseSave
+	pauseQuit
+)
+
+// pauseItems are the labels of the pause menu entries.
+var pauseItems = []string{"Resume", "Save", "Quit"}
+
+// pauseItemRects returns the screen rectangles of the pause menu entries, stacked in the
+// middle of the window for text drawn at the given scale.
+func pauseItemRects(window pixel.Rect, lineHeight, scale float64) []pixel.Rect {
+	size := pixel.V(120*scale, lineHeight*scale+10)
+	gap := 10.0
+	top := window.Center().Y + (float64(len(pauseItems))*(size.Y+gap)-gap)/2
+	rects := make([]pixel.Rect, len(pauseItems))
+	for i := range rects {
+		max := pixel.V(window.Center().X+size.X/2, top-float64(i)*(size.Y+gap))
+		rects[i] = pixel.Rect{Min: max.Sub(size), Max: max}
+	}
+	return rects
+}
+
+// drawPauseMenu dims the whole window and draws the pause menu entries over it, the selected
+// one highlighted. The text is rewritten into txt, drawn at the given scale.
+func drawPauseMenu(imd *imdraw.IMDraw, txt *text.Text, window pixel.Rect, scale float64, selected int) {
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.6))
+	imd.Push(window.Min, window.Max)
+	imd.Rectangle(0)
+
+	txt.Clear()
+	for i, r := range pauseItemRects(window, txt.LineHeight, scale) {
+		if i == selected {
+			imd.Color = pixel.RGB(0.31, 0.51, 0.15)
+		} else {
+			imd.Color = pixel.RGB(0.15, 0.15, 0.15).Mul(pixel.Alpha(0.9))
+		}
+		imd.Push(r.Min, r.Max)
+		imd.Rectangle(0)
+
+		// The text is laid out unscaled, it is scaled around the origin when drawn
+		label := pauseItems[i]
+		txt.Dot = r.Center().Scaled(1 / scale).Sub(pixel.V(txt.BoundsOf(label).W()/2, txt.LineHeight/4))
+		txt.WriteString(label)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"image"
+	"math"
+	"os"
+
+	_ "image/png" // Importing the PNG package to support loading PNG images
+
+	"github.com/faiface/pixel"
+)
+
+// loadPicture loads an image from a file and returns a pixel.Picture object.
+func loadPicture(path string) (pixel.Picture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return pixel.PictureDataFromImage(img), nil
+}
+
+// cutFrames cuts a spritesheet of the given bounds into square frames of the given size,
+// column by column from the bottom-left corner (the order frame indices refer to). Partial
+// frames at the right and top edges are dropped, and a sheet smaller than a frame, or a size
+// that isn't positive, gives no frames.
+func cutFrames(bounds pixel.Rect, size float64) []pixel.Rect {
+	if size <= 0 {
+		return nil
+	}
+	cols := int(math.Floor(bounds.W() / size))
+	rows := int(math.Floor(bounds.H() / size))
+	var frames []pixel.Rect
+	for c := 0; c < cols; c++ {
+		for r := 0; r < rows; r++ {
+			min := bounds.Min.Add(pixel.V(float64(c)*size, float64(r)*size))
+			frames = append(frames, pixel.Rect{Min: min, Max: min.Add(pixel.V(size, size))})
+		}
+	}
+	return frames
+}
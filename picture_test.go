@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestCutFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds pixel.Rect
+		size   float64
+		want   []pixel.Rect
+	}{
+		{"single frame", pixel.R(0, 0, 32, 32), 32, []pixel.Rect{pixel.R(0, 0, 32, 32)}},
+		{"row", pixel.R(0, 0, 96, 32), 32, []pixel.Rect{pixel.R(0, 0, 32, 32), pixel.R(32, 0, 64, 32), pixel.R(64, 0, 96, 32)}},
+		// Column by column, from the bottom-left corner
+		{"grid", pixel.R(0, 0, 64, 64), 32, []pixel.Rect{pixel.R(0, 0, 32, 32), pixel.R(0, 32, 32, 64), pixel.R(32, 0, 64, 32), pixel.R(32, 32, 64, 64)}},
+		{"off the origin", pixel.R(10, 20, 42, 84), 32, []pixel.Rect{pixel.R(10, 20, 42, 52), pixel.R(10, 52, 42, 84)}},
+		{"partial column", pixel.R(0, 0, 80, 32), 32, []pixel.Rect{pixel.R(0, 0, 32, 32), pixel.R(32, 0, 64, 32)}},
+		{"partial row", pixel.R(0, 0, 32, 63), 32, []pixel.Rect{pixel.R(0, 0, 32, 32)}},
+		{"partial column and row", pixel.R(0, 0, 70, 90), 32, []pixel.Rect{pixel.R(0, 0, 32, 32), pixel.R(0, 32, 32, 64), pixel.R(32, 0, 64, 32), pixel.R(32, 32, 64, 64)}},
+		{"smaller than a frame", pixel.R(0, 0, 31, 31), 32, nil},
+		{"narrower than a frame", pixel.R(0, 0, 16, 128), 32, nil},
+		{"empty sheet", pixel.R(0, 0, 0, 0), 32, nil},
+		{"zero size", pixel.R(0, 0, 64, 64), 0, nil},
+		{"negative size", pixel.R(0, 0, 64, 64), -32, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutFrames(tt.bounds, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("cutFrames(%v, %v) = %v, want %v", tt.bounds, tt.size, got, tt.want)
+			}
+		})
+	}
+}
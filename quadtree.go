@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	quadCapacity = 8  // Points a node holds before it splits
+	quadMaxDepth = 16 // Nodes this deep never split (many trees on the same spot)
+)
+
+// quadPoint is a tree position stored in the quadtree, with its index in the forest.
+type quadPoint struct {
+	pos   pixel.Vec
+	index int
+}
+
+// quadTree indexes tree positions for fast nearest-tree queries.
+type quadTree struct {
+	bounds   pixel.Rect
+	depth    int
+	points   []quadPoint  // Points of a leaf
+	outside  []quadPoint  // Points outside the bounds (root only)
+	children *[4]quadTree // Nil for a leaf
+}
+
+// newQuadTree returns an empty quadtree covering bounds. Points can still be inserted outside
+// the bounds, they are just not subdivided.
+func newQuadTree(bounds pixel.Rect) *quadTree {
+	return &quadTree{bounds: bounds.Norm()}
+}
+
+// buildQuadTree returns a quadtree over all the trees of the forest.
+func buildQuadTree(bounds pixel.Rect, trees []PlantedTree) *quadTree {
+	q := newQuadTree(bounds)
+	for i, t := range trees {
+		q.Insert(t.Pos(), i)
+	}
+	return q
+}
+
+// Insert adds the position of the tree at the given forest index.
+func (q *quadTree) Insert(pos pixel.Vec, index int) {
+	if !q.bounds.Contains(pos) {
+		q.outside = append(q.outside, quadPoint{pos, index})
+		return
+	}
+	q.insert(quadPoint{pos, index})
+}
+
+func (q *quadTree) insert(p quadPoint) {
+	if q.children != nil {
+		q.children[q.quadrant(p.pos)].insert(p)
+		return
+	}
+	q.points = append(q.points, p)
+	if len(q.points) > quadCapacity && q.depth < quadMaxDepth {
+		q.split()
+	}
+}
+
+// split turns a leaf into a node with four children and moves its points down.
+func (q *quadTree) split() {
+	c := q.bounds.Center()
+	min, max := q.bounds.Min, q.bounds.Max
+	q.children = &[4]quadTree{
+		{bounds: pixel.R(min.X, min.Y, c.X, c.Y), depth: q.depth + 1},
+		{bounds: pixel.R(c.X, min.Y, max.X, c.Y), depth: q.depth + 1},
+		{bounds: pixel.R(min.X, c.Y, c.X, max.Y), depth: q.depth + 1},
+		{bounds: pixel.R(c.X, c.Y, max.X, max.Y), depth: q.depth + 1},
+	}
+	points := q.points
+	q.points = nil
+	for _, p := range points {
+		q.children[q.quadrant(p.pos)].insert(p)
+	}
+}
+
+// quadrant returns the index of the child containing pos.
+func (q *quadTree) quadrant(pos pixel.Vec) int {
+	c := q.bounds.Center()
+	i := 0
+	if pos.X >= c.X {
+		i |= 1
+	}
+	if pos.Y >= c.Y {
+		i |= 2
+	}
+	return i
+}
+
+// Nearest returns the forest index of the tree closest to pos, only considering trees closer
+// than maxDist. It reports false when there is none.
+func (q *quadTree) Nearest(pos pixel.Vec, maxDist float64) (int, bool) {
+	best, bestDist := -1, maxDist
+	for _, p := range q.outside {
+		if d := p.pos.To(pos).Len(); d < bestDist {
+			best, bestDist = p.index, d
+		}
+	}
+	q.nearest(pos, &best, &bestDist)
+	return best, best >= 0
+}
+
+func (q *quadTree) nearest(pos pixel.Vec, best *int, bestDist *float64) {
+	for _, p := range q.points {
+		if d := p.pos.To(pos).Len(); d < *bestDist {
+			*best, *bestDist = p.index, d
+		}
+	}
+	if q.children == nil {
+		return
+	}
+	// Visit the child containing pos first, it most likely holds the nearest tree
+	first := q.quadrant(pos)
+	for i := 0; i < 4; i++ {
+		child := &q.children[first^i]
+		if rectDistance(child.bounds, pos) < *bestDist {
+			child.nearest(pos, best, bestDist)
+		}
+	}
+}
+
+// Within calls fn with the forest index of every tree closer than radius to pos.
+func (q *quadTree) Within(pos pixel.Vec, radius float64, fn func(index int)) {
+	for _, p := range q.outside {
+		if p.pos.To(pos).Len() < radius {
+			fn(p.index)
+		}
+	}
+	q.within(pos, radius, fn)
+}
+
+func (q *quadTree) within(pos pixel.Vec, radius float64, fn func(index int)) {
+	for _, p := range q.points {
+		if p.pos.To(pos).Len() < radius {
+			fn(p.index)
+		}
+	}
+	if q.children == nil {
+		return
+	}
+	for i := range q.children {
+		if rectDistance(q.children[i].bounds, pos) < radius {
+			q.children[i].within(pos, radius, fn)
+		}
+	}
+}
+
+// rectDistance returns the distance from pos to the closest point of r (0 inside r).
+func rectDistance(r pixel.Rect, pos pixel.Vec) float64 {
+	dx := math.Max(0, math.Max(r.Min.X-pos.X, pos.X-r.Max.X))
+	dy := math.Max(0, math.Max(r.Min.Y-pos.Y, pos.Y-r.Max.Y))
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// randomPoints returns n random positions, some of them outside bounds and some on the same
+// spot as others.
+func randomPoints(rng *rand.Rand, bounds pixel.Rect, n int) []pixel.Vec {
+	points := make([]pixel.Vec, n)
+	for i := range points {
+		switch {
+		case i > 0 && rng.Intn(10) == 0:
+			points[i] = points[rng.Intn(i)]
+		case rng.Intn(20) == 0:
+			points[i] = pixel.V(bounds.Max.X+rng.Float64()*100, bounds.Min.Y-rng.Float64()*100)
+		default:
+			points[i] = pixel.V(bounds.Min.X+rng.Float64()*bounds.W(), bounds.Min.Y+rng.Float64()*bounds.H())
+		}
+	}
+	return points
+}
+
+func TestQuadTreeMatchesBruteForce(t *testing.T) {
+	bounds := pixel.R(-500, -500, 500, 500)
+	for _, n := range []int{0, 1, quadCapacity, quadCapacity + 1, 100, 2000} {
+		rng := rand.New(rand.NewSource(int64(n)))
+		points := randomPoints(rng, bounds, n)
+		q := newQuadTree(bounds)
+		for i, p := range points {
+			q.Insert(p, i)
+		}
+
+		for query := 0; query < 200; query++ {
+			pos := pixel.V(-600+rng.Float64()*1200, -600+rng.Float64()*1200)
+			radius := rng.Float64() * 300
+
+			best, bestDist := -1, radius
+			var within []int
+			for i, p := range points {
+				d := p.To(pos).Len()
+				if d < bestDist {
+					best, bestDist = i, d
+				}
+				if d < radius {
+					within = append(within, i)
+				}
+			}
+
+			// Trees on the same spot are as near, any of them will do
+			i, ok := q.Nearest(pos, radius)
+			if ok != (best >= 0) || ok && points[i].To(pos).Len() != bestDist {
+				t.Fatalf("%d points: nearest to %v within %v = %d, %v, want %d", n, pos, radius, i, ok, best)
+			}
+
+			var got []int
+			q.Within(pos, radius, func(i int) { got = append(got, i) })
+			sort.Ints(got)
+			if !reflect.DeepEqual(got, within) {
+				t.Fatalf("%d points: within %v of %v = %v, want %v", n, radius, pos, got, within)
+			}
+		}
+	}
+}
+
+func TestQuadTreeSameSpot(t *testing.T) {
+	// More trees on one spot than a node holds even at the maximum depth
+	q := newQuadTree(pixel.R(0, 0, 100, 100))
+	for i := 0; i < 4*quadCapacity; i++ {
+		q.Insert(pixel.V(10, 10), i)
+	}
+	count := 0
+	q.Within(pixel.V(10, 10), 1, func(int) { count++ })
+	if count != 4*quadCapacity {
+		t.Fatalf("found %d trees, want %d", count, 4*quadCapacity)
+	}
+	if _, ok := q.Nearest(pixel.V(50, 50), 10); ok {
+		t.Fatal("found a tree farther than the maximum distance")
+	}
+}
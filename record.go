@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// recordedPlant is a line of a recording: a planted tree and when it was planted.
+type recordedPlant struct {
+	T    float64     `json:"t"`    // Seconds since the recording started
+	Tree PlantedTree `json:"tree"` // Planted tree
+}
+
+// recorder logs planted trees to a file, one JSON line per tree.
+type recorder struct {
+	file  *os.File
+	w     *bufio.Writer
+	start time.Time
+}
+
+// startRecording creates the recording file at path.
+func startRecording(path string) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{file: f, w: bufio.NewWriter(f), start: time.Now()}, nil
+}
+
+// Record logs a tree planted now. Write errors are reported by Close.
+func (r *recorder) Record(t PlantedTree) {
+	line, _ := json.Marshal(recordedPlant{T: time.Since(r.start).Seconds(), Tree: t})
+	r.w.Write(append(line, '\n'))
+}
+
+// Close flushes and closes the recording file.
+func (r *recorder) Close() error {
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// replay plants the trees of a recording again, keeping their order and relative timing.
+type replay struct {
+	plants []recordedPlant
+	speed  float64   // Playback speed factor (2 replays twice as fast)
+	start  time.Time // When the replay started (zero until the first Due)
+	next   int       // Index of the next tree to plant
+}
+
+// loadReplay reads a recording made by a recorder, to be played back at the given speed.
+func loadReplay(path string, speed float64) (*replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := &replay{speed: speed}
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var p recordedPlant
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+		r.plants = append(r.plants, p)
+	}
+	return r, nil
+}
+
+// Due returns the trees whose (scaled) time has come since the last call.
+func (r *replay) Due(now time.Time) []PlantedTree {
+	if r.start.IsZero() {
+		r.start = now
+	}
+	elapsed := now.Sub(r.start).Seconds() * r.speed
+	var due []PlantedTree
+	for ; r.next < len(r.plants) && r.plants[r.next].T <= elapsed; r.next++ {
+		due = append(due, r.plants[r.next].Tree)
+	}
+	return due
+}
+
+// Done reports whether every tree of the recording was planted.
+func (r *replay) Done() bool {
+	return r.next >= len(r.plants)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// canvasImage turns raw canvas pixels (rows starting at the bottom-left, as OpenGL stores them)
+// into an image with the usual top-left origin.
+func canvasImage(pixels []uint8, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	stride := w * 4
+	for y := 0; y < h; y++ {
+		src := pixels[(h-1-y)*stride : (h-y)*stride]
+		copy(img.Pix[y*img.Stride:y*img.Stride+stride], src)
+	}
+	return img
+}
+
+// saveScreenshot writes the current content of the canvas to a PNG file.
+func saveScreenshot(path string, canvas *pixelgl.Canvas) error {
+	bounds := canvas.Bounds()
+	img := canvasImage(canvas.Pixels(), int(bounds.W()), int(bounds.H()))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// findTrees returns the indices of the trees matching a search query: the trees whose label
+// contains it, ignoring case, and the trees of the sprite it names, like "3" or "tree 3" (the
+// numbers shown by the tooltip). An empty query matches nothing.
+func findTrees(trees []PlantedTree, query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	frame := -1
+	if n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(query, "tree"))); err == nil {
+		frame = n - 1
+	}
+	var found []int
+	for i, t := range trees {
+		if t.Frame == frame || strings.Contains(strings.ToLower(t.Label), query) {
+			found = append(found, i)
+		}
+	}
+	return found
+}
@@ -0,0 +1,38 @@
+package main
+
+// seeds is the resource spent to plant trees, regenerating over time up to its starting
+// amount. A nil *seeds never runs out, for when the seed economy is off.
+type seeds struct {
+	count float64 // Seeds available
+	max   float64 // Seeds regenerate up to this amount
+	regen float64 // Seeds regained per second
+	cost  float64 // Seeds spent per tree
+}
+
+// newSeeds returns a full stock of seeds.
+func newSeeds(start, regen, cost float64) *seeds {
+	return &seeds{count: start, max: start, regen: regen, cost: cost}
+}
+
+// Update regenerates the seeds for dt seconds.
+func (s *seeds) Update(dt float64) {
+	if s == nil {
+		return
+	}
+	s.count += s.regen * dt
+	if s.count > s.max {
+		s.count = s.max
+	}
+}
+
+// Spend spends the seeds of a tree and reports whether there were enough.
+func (s *seeds) Spend() bool {
+	if s == nil {
+		return true
+	}
+	if s.count < s.cost {
+		return false
+	}
+	s.count -= s.cost
+	return true
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// setting is an entry of the settings overlay, editing a live value of the game.
+type setting struct {
+	name   string
+	value  func() string // Current value, formatted
+	adjust func(dir int) // Steps the value up (1) or down (-1), or flips a toggle
+}
+
+// onOff formats a toggle.
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+// writeSettings writes the settings overlay, marking the selected entry.
+func writeSettings(w io.Writer, settings []setting, selected int) {
+	fmt.Fprintln(w, "Settings")
+	for i, s := range settings {
+		marker := " "
+		if i == selected {
+			marker = ">"
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", marker, s.name, s.value())
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// shadowLayers is the number of stacked ellipses making up a shadow, each smaller one darker,
+// which blurs its edge.
+const shadowLayers = 3
+
+// drawShadows draws a soft elliptical shadow at the foot of every tree inside view, offset
+// below the trunk by offset frame pixels. Shadows are scaled with their tree.
+func drawShadows(imd *imdraw.IMDraw, trees []PlantedTree, frames []pixel.Rect, view pixel.Rect, opacity, offset float64) {
+	layer := pixel.Alpha(opacity / shadowLayers)
+	for _, t := range trees {
+		if !view.Contains(t.Pos()) {
+			continue
+		}
+		frame := frames[t.Frame]
+		center := t.Pos().Sub(pixel.V(0, (frame.H()/2+offset)*t.Scale))
+		radius := pixel.V(frame.W()*0.35, frame.W()*0.12).Scaled(t.Scale)
+		for i := 0; i < shadowLayers; i++ {
+			imd.Color = pixel.RGB(0, 0, 0).Mul(layer)
+			imd.Push(center)
+			imd.Ellipse(radius.Scaled(1-float64(i)/(shadowLayers+1)), 0)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// parseColor parses a color written as "#RRGGBB" (the # is optional).
+func parseColor(s string) (pixel.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return pixel.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB", s)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return pixel.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB", s)
+	}
+	return pixel.RGB(float64(n>>16&0xFF)/255, float64(n>>8&0xFF)/255, float64(n&0xFF)/255), nil
+}
+
+// drawGradient pushes a rectangle fading vertically from top to bottom into imd.
+func drawGradient(imd *imdraw.IMDraw, r pixel.Rect, top, bottom pixel.RGBA) {
+	imd.Color = top
+	imd.Push(pixel.V(r.Min.X, r.Max.Y), r.Max)
+	imd.Color = bottom
+	imd.Push(pixel.V(r.Max.X, r.Min.Y), r.Min)
+	imd.Polygon(0)
+}
+
+// vignetteSegments is the number of sides of the ellipses the vignette fades between.
+const vignetteSegments = 48
+
+// drawVignette pushes a ring over r into imd, transparent around the center and fading out
+// toward color at the edges, reaching strength opacity at the corners.
+func drawVignette(imd *imdraw.IMDraw, r pixel.Rect, color pixel.RGBA, strength float64) {
+	center, half := r.Center(), r.Size().Scaled(0.5)
+	// The fade starts at 60% of the way to the edges, and the outer ellipse is wide enough to
+	// cover the corners, which are 90% of the way to it
+	inner, outer := half.Scaled(0.6), half.Scaled(1.5)
+	edge := color.Mul(pixel.Alpha(strength / 0.9))
+	at := func(radii pixel.Vec, i int) pixel.Vec {
+		angle := 2 * math.Pi * float64(i) / vignetteSegments
+		return center.Add(pixel.V(math.Cos(angle)*radii.X, math.Sin(angle)*radii.Y))
+	}
+	for i := 0; i < vignetteSegments; i++ {
+		imd.Color = pixel.Alpha(0)
+		imd.Push(at(inner, i), at(inner, i+1))
+		imd.Color = edge
+		imd.Push(at(outer, i+1), at(outer, i))
+		imd.Polygon(0)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/speaker"
+	"github.com/faiface/beep/vorbis"
+	"github.com/faiface/beep/wav"
+)
+
+// soundRate is the sample rate of the speaker, every sound is resampled to it.
+const soundRate = beep.SampleRate(44100)
+
+// sound plays the looping background music and the plant sound. A nil *sound is silent.
+type sound struct {
+	mixer  *beep.Mixer
+	volume *effects.Volume
+	plop   *beep.Buffer // Plant sound, nil when none was given
+	pitch  float64      // Largest change of the plant sound's playback rate, as a fraction
+	rng    *rand.Rand   // Picks the playback rates, apart from the game's so planting doesn't depend on the sound
+}
+
+// loadSound opens the speaker and starts the music (either path may be empty). volume goes
+// from 0 (silent) to 1 (full volume). Each plant sound is played up to pitch faster or slower
+// (0.1 is 10%), at rates drawn from seed.
+func loadSound(musicPath, plopPath string, volume, pitch float64, seed int64) (*sound, error) {
+	if musicPath == "" && plopPath == "" {
+		return nil, nil
+	}
+	s := &sound{mixer: &beep.Mixer{}, pitch: pitch, rng: rand.New(rand.NewSource(seed))}
+	s.volume = &effects.Volume{Streamer: s.mixer, Base: 2, Volume: math.Log2(volume), Silent: volume <= 0}
+
+	if plopPath != "" {
+		stream, format, err := decodeAudio(plopPath)
+		if err != nil {
+			return nil, err
+		}
+		s.plop = beep.NewBuffer(beep.Format{SampleRate: soundRate, NumChannels: 2, Precision: 2})
+		s.plop.Append(beep.Resample(4, format.SampleRate, soundRate, stream))
+		stream.Close()
+	}
+	if musicPath != "" {
+		// The music is streamed from its file for as long as the game runs
+		stream, format, err := decodeAudio(musicPath)
+		if err != nil {
+			return nil, err
+		}
+		s.mixer.Add(beep.Resample(4, format.SampleRate, soundRate, beep.Loop(-1, stream)))
+	}
+
+	if err := speaker.Init(soundRate, soundRate.N(time.Second/10)); err != nil {
+		return nil, err
+	}
+	speaker.Play(s.volume)
+	return s, nil
+}
+
+// decodeAudio opens a WAV, MP3 or Ogg Vorbis file, chosen by its extension.
+func decodeAudio(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return wav.Decode(f)
+	case ".mp3":
+		return mp3.Decode(f)
+	case ".ogg":
+		return vorbis.Decode(f)
+	}
+	f.Close()
+	return nil, beep.Format{}, fmt.Errorf("%s: unsupported audio format (use .wav, .mp3 or .ogg)", path)
+}
+
+// Plop plays the plant sound over the music at a slightly random pitch, mixed with the plant
+// sounds still playing rather than cutting them off.
+func (s *sound) Plop() {
+	if s == nil || s.plop == nil {
+		return
+	}
+	ratio := 1 + s.pitch*(2*s.rng.Float64()-1)
+	speaker.Lock()
+	s.mixer.Add(beep.ResampleRatio(4, ratio, s.plop.Streamer(0, s.plop.Len())))
+	speaker.Unlock()
+}
+
+// ToggleMute mutes or unmutes every sound and reports whether it is now muted. Without sound
+// there is nothing to mute.
+func (s *sound) ToggleMute() bool {
+	if s == nil {
+		return false
+	}
+	speaker.Lock()
+	defer speaker.Unlock()
+	s.volume.Silent = !s.volume.Silent
+	return s.volume.Silent
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestNoSound(t *testing.T) {
+	// Without -music and -plantsound there is no sound, and nothing muted
+	s, err := loadSound("", "", 1, 0.1, 1)
+	if err != nil || s != nil {
+		t.Fatalf("loadSound = %v, %v, want no sound", s, err)
+	}
+	for i := 0; i < 2; i++ {
+		if s.ToggleMute() {
+			t.Fatal("ToggleMute reports no sound as muted")
+		}
+	}
+	s.Plop()
+}
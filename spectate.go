@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// spectators streams the forest events as JSON over WebSocket, so a web page can show the
+// forest live. New spectators first receive a "plant" event for every tree already standing.
+type spectators struct {
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	trees   []PlantedTree                   // Current forest, kept from the events for new spectators
+	clients map[*websocket.Conn]chan []byte // Outgoing queue of each spectator
+}
+
+// serveSpectators starts a WebSocket server on addr streaming the events of the forest.
+func serveSpectators(addr string, forest *Forest) (*spectators, error) {
+	s := &spectators{
+		// Spectators only read, any page may watch
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		trees:    append([]PlantedTree(nil), forest.Trees...),
+		clients:  map[*websocket.Conn]chan []byte{},
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(s.handle)}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			fmt.Fprintf(os.Stderr, "trees: websocket: %v\n", err)
+		}
+	}()
+	forest.Subscribe(s.publish)
+	return s, nil
+}
+
+// handle upgrades a request to a WebSocket and sends it the forest followed by its events.
+func (s *spectators) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	out := make(chan []byte, len(s.trees)+256)
+	for i := range s.trees {
+		out <- encodeEvent(ForestEvent{Type: EventPlant, Tree: &s.trees[i]})
+	}
+	s.clients[conn] = out
+	s.mu.Unlock()
+
+	// Writer
+	go func() {
+		for msg := range out {
+			if conn.WriteMessage(websocket.TextMessage, msg) != nil {
+				break
+			}
+		}
+		conn.Close()
+	}()
+
+	// Reader, only there to notice the spectator leaving
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				s.mu.Lock()
+				s.drop(conn)
+				s.mu.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// publish records a forest event and queues it for every spectator. Spectators too slow to
+// keep up are dropped rather than blocking the game.
+func (s *spectators) publish(e ForestEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e.Type {
+	case EventPlant:
+		s.trees = append(s.trees, *e.Tree)
+	case EventRemove:
+		for i := len(s.trees) - 1; i >= 0; i-- {
+			if s.trees[i] == *e.Tree {
+				s.trees = append(s.trees[:i], s.trees[i+1:]...)
+				break
+			}
+		}
+	case EventMove, EventLabel:
+		for i := len(s.trees) - 1; i >= 0; i-- {
+			if s.trees[i] == *e.From {
+				s.trees[i] = *e.Tree
+				break
+			}
+		}
+	case EventClear:
+		s.trees = nil
+	}
+	msg := encodeEvent(e)
+	for conn, out := range s.clients {
+		select {
+		case out <- msg:
+		default:
+			s.drop(conn)
+		}
+	}
+}
+
+// drop forgets a spectator and closes its connection. s.mu must be held.
+func (s *spectators) drop(conn *websocket.Conn) {
+	if out, ok := s.clients[conn]; ok {
+		delete(s.clients, conn)
+		close(out)
+		conn.Close()
+	}
+}
+
+// encodeEvent returns the JSON form of a forest event.
+func encodeEvent(e ForestEvent) []byte {
+	msg, _ := json.Marshal(e) // Only plain numbers and strings, can't fail
+	return msg
+}
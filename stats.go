@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// plantRate remembers when the latest trees were planted, in a ring buffer, to compute a planting rate.
+type plantRate struct {
+	times [256]time.Time
+	next  int
+}
+
+// add records a tree planted at t.
+func (r *plantRate) add(t time.Time) {
+	r.times[r.next] = t
+	r.next = (r.next + 1) % len(r.times)
+}
+
+// perSecond returns the number of trees planted per second over the window ending at now.
+func (r *plantRate) perSecond(now time.Time, window time.Duration) float64 {
+	n := 0
+	for _, t := range r.times {
+		if !t.IsZero() && now.Sub(t) <= window {
+			n++
+		}
+	}
+	return float64(n) / window.Seconds()
+}
+
+// frameCounts returns how many trees of each sprite frame the forest holds.
+func frameCounts(trees []PlantedTree, frameCount int) []int {
+	counts := make([]int, frameCount)
+	for _, t := range trees {
+		if t.Frame >= 0 && t.Frame < frameCount {
+			counts[t.Frame]++
+		}
+	}
+	return counts
+}
+
+// writeStats writes the statistics panel text.
+func writeStats(w io.Writer, trees []PlantedTree, frameCount int, rate, zoom float64, smooth bool) {
+	fmt.Fprintln(w, "Statistics")
+	fmt.Fprintf(w, "Trees: %d\n", len(trees))
+	for i, n := range frameCounts(trees, frameCount) {
+		fmt.Fprintf(w, "  Tree %d: %d\n", i+1, n)
+	}
+	fmt.Fprintf(w, "Rate: %.1f trees/s\n", rate)
+	fmt.Fprintf(w, "Zoom: %.2fx\n", zoom)
+	if smooth {
+		fmt.Fprintln(w, "Sampling: Smooth")
+	} else {
+		fmt.Fprintln(w, "Sampling: Pixel")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	tourCell  = 600.0 // Size of the cells trees are grouped in to find the tour stops, in world units
+	tourDwell = 2.0   // Seconds the camera stays over a stop before moving on
+)
+
+// tourStops groups the trees by cells of the given size and returns the centroid of each
+// group, ordered into a tour by always going to the nearest stop left, starting from from.
+func tourStops(trees []PlantedTree, cell float64, from pixel.Vec) []pixel.Vec {
+	type group struct {
+		sum pixel.Vec
+		n   int
+	}
+	groups := map[[2]int]*group{}
+	var keys [][2]int // Groups in order of appearance, so the tour doesn't depend on map order
+	for _, t := range trees {
+		k := [2]int{int(math.Floor(t.X / cell)), int(math.Floor(t.Y / cell))}
+		g, ok := groups[k]
+		if !ok {
+			g = &group{}
+			groups[k] = g
+			keys = append(keys, k)
+		}
+		g.sum = g.sum.Add(t.Pos())
+		g.n++
+	}
+	left := make([]pixel.Vec, len(keys))
+	for i, k := range keys {
+		left[i] = groups[k].sum.Scaled(1 / float64(groups[k].n))
+	}
+
+	stops := make([]pixel.Vec, 0, len(left))
+	for len(left) > 0 {
+		nearest := 0
+		for i, p := range left {
+			if from.To(p).Len() < from.To(left[nearest]).Len() {
+				nearest = i
+			}
+		}
+		from = left[nearest]
+		stops = append(stops, from)
+		left = append(left[:nearest], left[nearest+1:]...)
+	}
+	return stops
+}
+
+// tour moves the camera from stop to stop, easing in and out of each one, and starts over
+// after the last.
+type tour struct {
+	stops    []pixel.Vec
+	next     int       // Stop the camera is heading to
+	from     pixel.Vec // Where the current leg started
+	progress float64   // Progress along the current leg, from 0 to 1
+	dwell    float64   // Seconds left to stay over the last stop reached
+}
+
+// newTour starts a tour of the stops from the camera position.
+func newTour(stops []pixel.Vec, pos pixel.Vec) *tour {
+	return &tour{stops: stops, from: pos}
+}
+
+// Update advances the tour by dt seconds at the given average speed, in world units per
+// second, and returns the camera position.
+func (t *tour) Update(dt, speed float64) pixel.Vec {
+	if t.dwell > 0 {
+		t.dwell -= dt
+		return t.from
+	}
+	to := t.stops[t.next]
+	if dist := t.from.To(to).Len(); dist > 0 {
+		t.progress += speed * dt / dist
+	} else {
+		t.progress = 1
+	}
+	if t.progress >= 1 {
+		t.from, t.progress, t.dwell = to, 0, tourDwell
+		t.next = (t.next + 1) % len(t.stops)
+		return to
+	}
+	return pixel.Lerp(t.from, to, smoothstep(t.progress))
+}
+
+// glide moves the camera once to a position in a fixed time, easing in and out like a leg of
+// a tour.
+type glide struct {
+	from, to pixel.Vec
+	progress float64 // Progress from 0 to 1
+}
+
+// glideDuration is the time a glide takes, in seconds, however far it goes.
+const glideDuration = 0.6
+
+// newGlide starts a glide of the camera from one position to another.
+func newGlide(from, to pixel.Vec) *glide {
+	return &glide{from: from, to: to}
+}
+
+// Update advances the glide by dt seconds and returns the camera position, and whether it
+// arrived.
+func (g *glide) Update(dt float64) (pixel.Vec, bool) {
+	g.progress += dt / glideDuration
+	if g.progress >= 1 {
+		return g.to, true
+	}
+	return pixel.Lerp(g.from, g.to, smoothstep(g.progress)), false
+}
+
+// smoothstep eases a progress from 0 to 1, slow at both ends.
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+)
+
+// treeListWidth is the width of the tree list panel, in characters of the font.
+const treeListWidth = 22
+
+// treeList places the side panel listing the planted trees, one row per tree, on the left of
+// the window above the frame time graph.
+type treeList struct {
+	screen pixel.Rect // Where the panel is drawn, in screen coordinates
+	row    float64    // Height of a row, in screen pixels
+	scale  float64    // Scale the text is drawn at
+}
+
+// newTreeList places the tree list in a window for text drawn at the given scale.
+func newTreeList(window pixel.Rect, lineHeight, advance, scale float64) treeList {
+	width := treeListWidth*advance*scale + 16
+	return treeList{
+		screen: pixel.R(window.Min.X+10, window.Min.Y+120, window.Min.X+10+width, window.Max.Y-120),
+		row:    lineHeight * scale,
+		scale:  scale,
+	}
+}
+
+// rows returns how many rows fit in the panel.
+func (l treeList) rows() int {
+	n := int((l.screen.H() - 16) / l.row)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// clampScroll keeps the index of the first tree shown so that the panel stays filled with
+// the count trees, as much as there are.
+func (l treeList) clampScroll(scroll, count int) int {
+	if scroll > count-l.rows() {
+		scroll = count - l.rows()
+	}
+	if scroll < 0 {
+		scroll = 0
+	}
+	return scroll
+}
+
+// indexAt returns the index of the tree in the row under a screen position, with the list
+// scrolled to scroll. It reports false outside of the rows of the count trees.
+func (l treeList) indexAt(v pixel.Vec, scroll, count int) (int, bool) {
+	if !l.screen.Contains(v) {
+		return 0, false
+	}
+	row := int((l.screen.Max.Y - 8 - v.Y) / l.row)
+	if row < 0 || row >= l.rows() || scroll+row >= count {
+		return 0, false
+	}
+	return scroll + row, true
+}
+
+// draw draws the panel background and the row of the hovered tree (-1 for none) into imd, and
+// rewrites the rows into txt, drawn at the list scale.
+func (l treeList) draw(imd *imdraw.IMDraw, txt *text.Text, trees []PlantedTree, scroll, hovered int) {
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.7))
+	imd.Push(l.screen.Min, l.screen.Max)
+	imd.Rectangle(0)
+
+	txt.Clear()
+	for row := 0; row < l.rows() && scroll+row < len(trees); row++ {
+		i := scroll + row
+		top := l.screen.Max.Y - 8 - float64(row)*l.row
+		if i == hovered {
+			imd.Color = pixel.RGB(0.31, 0.51, 0.15)
+			imd.Push(pixel.V(l.screen.Min.X+4, top-l.row), pixel.V(l.screen.Max.X-4, top))
+			imd.Rectangle(0)
+		}
+		// The text is laid out unscaled, it is scaled around the origin when drawn
+		txt.Dot = pixel.V(l.screen.Min.X+8, top-l.row+txt.LineHeight*l.scale/4).Scaled(1 / l.scale)
+		fmt.Fprintf(txt, "#%-5d %7.0f %7.0f", i, trees[i].X, trees[i].Y)
+	}
+}
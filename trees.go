@@ -1,182 +1,349 @@
-package main
-
-import (
-	// Basic packages
-	"fmt"
-	"image"
-	"math"
-	"math/rand"
-	"os"
-	"time"
-
-	_ "image/png" // Importing the PNG package to support loading PNG images
-
-	"github.com/faiface/pixel"          // Importing the Pixel library
-	"github.com/faiface/pixel/pixelgl"  // OpenGL from Pixel library
-	"github.com/faiface/pixel/text"     // Text from pixel library
-	"golang.org/x/image/font/basicfont" // Import basic fonts
-)
-
-// loadPicture loads an image from a file and returns a pixel.Picture object.
-func loadPicture(path string) (pixel.Picture, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-	img, _, err := image.Decode(file)
-	if err != nil {
-		return nil, err
-	}
-	return pixel.PictureDataFromImage(img), nil
-}
-
-// Declare the treeCountLabel variable outside the run function
-var treeCountLabel *text.Text
-
-// run is the main game loop where game logic is implemented.
-func run() {
-	// Window configuration
-	cfg := pixelgl.WindowConfig{
-		Title:  "Trees!",                 // Window title
-		Bounds: pixel.R(0, 0, 1024, 768), // Window size
-		VSync:  true,                     // Enable VSync (synchronizes frame rate with monitor refresh rate)
-	}
-	// Create a new window
-	win, err := pixelgl.NewWindow(cfg)
-	if err != nil {
-		panic(err)
-	}
-
-	// Declare some variables
-	var (
-		windowSize       = pixel.V(1024, 768)     // Window size
-		camPos           = windowSize.Scaled(0.5) // Camera position
-		camSpeed         = 500.0                  // Camera speed
-		camZoom          = 1.0                    // Initial camera zoom level
-		minZoom          = 0.2                    // Minimum zoom level
-		maxZoom          = 2.0                    // Maximum zoom level
-		camZoomSpeed     = 1.2                    // Camera zoom speed
-		treesPlanted     = 0                      // Number of trees planted
-		initialFontScale = 2.0                    // Initial font scale
-		frames           = 0                      // Frames counter initial value
-		second           = time.Tick(time.Second) // Tick in seconds
-	)
-
-	// Define text fonts
-	basicAtlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
-	// Text position at start
-	basicTxt := text.New(pixel.V(windowSize.X/1.20-camPos.X, windowSize.Y/0.90-camPos.Y), basicAtlas)
-
-	// Author variable and print text with fmt
-	author := "Jordan"
-	fmt.Fprintln(basicTxt, "Controls:")
-	fmt.Fprintln(basicTxt, "- Arrows: Move Camera")
-	fmt.Fprintln(basicTxt, "- Scroll: Zoom")
-	fmt.Fprintln(basicTxt, "- Left Click: Plant Tree")
-	fmt.Fprintln(basicTxt, "\nJust have fun planting trees!")
-	fmt.Fprintf(basicTxt, "- %s", author)
-
-	// Load the spritesheet image for trees
-	spritesheet, err := loadPicture("trees.png")
-	if err != nil {
-		panic(err)
-	}
-
-	// First batch (trees)
-	batch := pixel.NewBatch(&pixel.TrianglesData{}, spritesheet)
-
-	// Prepare tree frames from the spritesheet (cut them from the spritesheet)
-	var treesFrames []pixel.Rect
-	for x := spritesheet.Bounds().Min.X; x < spritesheet.Bounds().Max.X; x += 32 {
-		for y := spritesheet.Bounds().Min.Y; y < spritesheet.Bounds().Max.Y; y += 32 {
-			treesFrames = append(treesFrames, pixel.R(x, y, x+32, y+32))
-		}
-	}
-
-	// Enable texture filtering (makes the image smoother) (keep commented)
-	// win.SetSmooth(true)
-
-	last := time.Now()
-
-	// Game loop using a for loop
-	for !win.Closed() {
-		dt := time.Since(last).Seconds()
-		last = time.Now()
-		cam := pixel.IM.Scaled(camPos, camZoom).Moved(win.Bounds().Center().Sub(camPos))
-		win.SetMatrix(cam)
-
-		// Calculate the position of the tree count label
-		countTxtPos := win.Bounds().Min.Add(pixel.V(5, win.Bounds().H()-25))
-		countTxtPos = cam.Unproject(countTxtPos)
-
-		// // Declare treeCountLabel variable
-		treeCountLabel := text.New(countTxtPos, basicAtlas)
-
-		// Draw tree count label
-		fmt.Fprintf(treeCountLabel, "Trees planted: %d", treesPlanted)
-
-		// Escape key to quit
-		if win.JustPressed(pixelgl.KeyEscape) {
-			break
-		}
-
-		// Mouse button left to plant tree
-		if win.JustPressed(pixelgl.MouseButtonLeft) {
-			tree := pixel.NewSprite(spritesheet, treesFrames[rand.Intn(len(treesFrames))])
-			mouse := cam.Unproject(win.MousePosition())
-			// Draws a random tree from the spritesheet
-			tree.Draw(batch, pixel.IM.Scaled(pixel.ZV, 4).Moved(mouse))
-			treesPlanted++
-		}
-
-		// Arrow key to move camera left
-		if win.Pressed(pixelgl.KeyLeft) {
-			camPos.X -= camSpeed * dt
-		}
-		// Arrow key to move camera right
-		if win.Pressed(pixelgl.KeyRight) {
-			camPos.X += camSpeed * dt
-		}
-		// Arrow key to move camera down
-		if win.Pressed(pixelgl.KeyDown) {
-			camPos.Y -= camSpeed * dt
-		}
-		// Arrow key to move camera up
-		if win.Pressed(pixelgl.KeyUp) {
-			camPos.Y += camSpeed * dt
-		}
-
-		// Adjust zoom level with mouse wheel
-		camZoom *= math.Pow(camZoomSpeed, win.MouseScroll().Y)
-		// Clamp the zoom level to stay within the specified limits
-		camZoom = math.Max(minZoom, math.Min(maxZoom, camZoom))
-
-		// Set the background color to grass green #4F8227
-		win.Clear(pixel.RGB(0x4F, 0x82, 0x27).Scaled(1.0 / 255))
-		// Draws images in batch 1
-		batch.Draw(win)
-		// Draw tuto text to screen
-		basicTxt.Draw(win, pixel.IM.Scaled(basicTxt.Orig, 2))
-
-		// Draw the treeCountLabel text
-		treeCountLabel.Draw(win, pixel.IM.Scaled(treeCountLabel.Orig, initialFontScale/camZoom))
-
-		// Update the game constantly
-		win.Update()
-
-		// Check FPS and put it in window frame
-		frames++
-		select {
-		case <-second:
-			win.SetTitle(fmt.Sprintf("%s | FPS: %d", cfg.Title, frames))
-			frames = 0
-		default:
-		}
-	}
-}
-
-// Starts the program
-func main() {
-	pixelgl.Run(run) // Run the game loop defined in the run() function
-}
+package main
+
+import (
+	// Basic packages
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel"         // Importing the Pixel library
+	"github.com/faiface/pixel/pixelgl" // OpenGL from Pixel library
+)
+
+// savePath is the forest save file, loaded on startup.
+const savePath = "forest.json"
+
+// autosavePath is where the forest is saved periodically, offered for recovery on startup
+// when it is newer than the save file.
+const autosavePath = "autosave.json"
+
+// worldBounds is the area the camera view is kept inside, and generated forests cover.
+var worldBounds = pixel.R(-2000, -2000, 2000, 2000)
+
+// brushKeys are the number keys used to select a tree variety, in frame order
+var brushKeys = []pixelgl.Button{
+	pixelgl.Key1, pixelgl.Key2, pixelgl.Key3, pixelgl.Key4, pixelgl.Key5,
+	pixelgl.Key6, pixelgl.Key7, pixelgl.Key8, pixelgl.Key9,
+}
+
+// options holds the settings given on the command line.
+type options struct {
+	spritesheet string      // Path to the tree spritesheet
+	frameSize   int         // Size in pixels of a single spritesheet frame
+	config      Config      // Settings loaded from the config file, with the command-line overrides
+	fileConfig  Config      // Settings as loaded from the config file, which the settings overlay saves its changes over
+	configPath  string      // Path of the config file, where the settings overlay saves changes
+	keys        Keybindings // Buttons of the actions
+	seed        int64       // Seed of the random choices made when planting
+	server      string      // Address to serve a collaborative session on
+	connect     string      // Address of a collaborative session to join
+	ws          string      // Address to stream the forest events on over WebSocket
+	replay      string      // Recording to replay
+	generate    int         // Number of trees to generate a forest with (0 loads the saved forest)
+	music       string      // Background music file
+	plantSound  string      // Sound file played when a tree is planted
+	ground      string      // Ground texture tiled under the trees (empty for the solid color)
+	helpText    string      // File of the tutorial text shown instead of the controls
+	font        string      // TrueType or OpenType font of the HUD (empty for the bitmap font)
+	fontSize    float64     // Size in pixels the HUD font is loaded at
+	headless    bool        // Export the forest without opening a window
+	benchmark   int         // Number of trees to plant in a benchmark (0 plays normally)
+	out         string      // File the headless forest is exported to
+	camSet      bool        // The starting camera was given with -zoom or -pos, over the saved one
+}
+
+// run opens the window and drives the game loop, the game itself is implemented by Game. It
+// returns why the game couldn't start or stopped with an error.
+func run(opts options) error {
+	// Window configuration
+	cfg := pixelgl.WindowConfig{
+		Title:     "Trees!",                 // Window title
+		Bounds:    pixel.R(0, 0, 1024, 768), // Window size
+		VSync:     opts.config.VSync,        // Synchronize the frame rate with the monitor refresh rate, unless disabled
+		Resizable: true,                     // Allow resizing the window
+	}
+	// Create a new window
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		return fmt.Errorf("cannot open the window: %v", err)
+	}
+	defer win.Destroy()
+
+	game, err := NewGame(opts, win)
+	if err != nil {
+		return err
+	}
+
+	titleTick := time.Tick(time.Second / 4) // Tick to refresh the FPS in the title
+	pacing := framePacing(opts.config.VSync, opts.config.FPSCap)
+	last := time.Now()
+
+	// Game loop using a for loop
+	for !win.Closed() {
+		dt := time.Since(last).Seconds()
+		last = time.Now()
+
+		game.HandleInput(win, dt)
+		if !game.quit {
+			game.Update(dt)
+		}
+		if game.quit {
+			break
+		}
+		game.Draw(win)
+
+		// Update the game constantly
+		win.Update()
+
+		// Sleep out the rest of the frame budget when the frame rate is capped
+		if opts.config.FPSCap > 0 {
+			if rest := time.Duration(float64(time.Second)/opts.config.FPSCap) - time.Since(last); rest > 0 {
+				time.Sleep(rest)
+			}
+		}
+
+		// Put the average FPS in the window title, with how the frames are paced
+		select {
+		case <-titleTick:
+			if fps := game.FPS(); fps > 0 {
+				win.SetTitle(fmt.Sprintf("%s | FPS: %.0f | %s", cfg.Title, fps, pacing))
+			}
+		default:
+		}
+	}
+
+	// Finish the recording left running
+	return game.Close()
+}
+
+// ctrlPressed reports whether either Control key is held down.
+func ctrlPressed(win *pixelgl.Window) bool {
+	return win.Pressed(pixelgl.KeyLeftControl) || win.Pressed(pixelgl.KeyRightControl)
+}
+
+// shiftPressed reports whether either Shift key is held down.
+func shiftPressed(win *pixelgl.Window) bool {
+	return win.Pressed(pixelgl.KeyLeftShift) || win.Pressed(pixelgl.KeyRightShift)
+}
+
+// editText returns s edited by the keys of the frame: the typed characters are added (see
+// appendTyped), and Backspace deletes the last one.
+func editText(win *pixelgl.Window, s string) string {
+	s = appendTyped(s, win.Typed())
+	if (win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace)) && len(s) > 0 {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// enterPressed reports whether either Enter key was just pressed.
+func enterPressed(win *pixelgl.Window) bool {
+	return win.JustPressed(pixelgl.KeyEnter) || win.JustPressed(pixelgl.KeyKPEnter)
+}
+
+// framePacing describes what limits the frame rate, for the window title and the benchmark
+// report.
+func framePacing(vsync bool, fpsCap float64) string {
+	pacing := "VSync on"
+	if !vsync {
+		pacing = "VSync off"
+	}
+	if fpsCap > 0 {
+		pacing += fmt.Sprintf(", capped at %g FPS", fpsCap)
+	}
+	return pacing
+}
+
+// parsePos parses a world position written as "x,y".
+func parsePos(s string) (pixel.Vec, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return pixel.ZV, fmt.Errorf("%q is not x,y", s)
+	}
+	x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errX != nil || errY != nil {
+		return pixel.ZV, fmt.Errorf("%q is not x,y", s)
+	}
+	return pixel.V(x, y), nil
+}
+
+// Starts the program
+func main() {
+	// Command-line flags
+	var opts options
+	flag.StringVar(&opts.spritesheet, "spritesheet", "trees.png", "path to the tree spritesheet image")
+	flag.IntVar(&opts.frameSize, "framesize", 32, "size in pixels of a spritesheet frame")
+	configPath := flag.String("config", "config.json", "path to the JSON settings file")
+	keysPath := flag.String("keybindings", "keybindings.json", "path to the JSON keybindings file")
+	flag.Int64Var(&opts.seed, "seed", 0, "seed for the random tree choices (default: time-based)")
+	flag.StringVar(&opts.server, "server", "", "serve a collaborative planting session on this address (e.g. :7777)")
+	flag.StringVar(&opts.connect, "connect", "", "join the collaborative planting session at host:port")
+	flag.StringVar(&opts.music, "music", "", "background music to loop (.wav, .mp3 or .ogg)")
+	flag.StringVar(&opts.plantSound, "plantsound", "", "sound played when a tree is planted (.wav, .mp3 or .ogg)")
+	flag.StringVar(&opts.ground, "ground", "", "tileable ground texture drawn under the trees (default: solid color)")
+	flag.StringVar(&opts.helpText, "helptext", "", "text file shown as the tutorial instead of the controls")
+	flag.StringVar(&opts.font, "font", "", "TrueType or OpenType font of the tutorial and tree count (default: the built-in bitmap font)")
+	flag.Float64Var(&opts.fontSize, "fontsize", defaultFontSize, "size in pixels of the -font font")
+	flag.IntVar(&opts.generate, "generate", 0, "start with a generated forest of this many trees instead of the saved one")
+	flag.StringVar(&opts.replay, "replay", "", "replay a recording made with L as a time-lapse")
+	flag.StringVar(&opts.ws, "ws", "", "stream the forest events as JSON over WebSocket on this address (e.g. :8080)")
+	flag.BoolVar(&opts.headless, "headless", false, "export the generated (or saved) forest to -out without opening a window, then exit")
+	flag.StringVar(&opts.out, "out", "forest.csv", "file -headless exports the forest to (.csv, or else JSON)")
+	flag.IntVar(&opts.benchmark, "benchmark", 0, "plant this many trees as fast as possible, print the planting and rendering performance and exit")
+	supersample := flag.Float64("supersample", 0, "draw the world at this many times the window resolution and downsample it, for smoother edges (default: supersample from the config)")
+	zoom := flag.Float64("zoom", 0, "zoom level at startup, between the min and max zoom (default: cam_zoom from the config)")
+	minZoom := flag.Float64("minzoom", 0, "smallest zoom level, to zoom further out (default: min_zoom from the config)")
+	maxZoom := flag.Float64("maxzoom", 0, "largest zoom level, to zoom further in (default: max_zoom from the config)")
+	zoomStep := flag.Float64("zoomstep", 0, "zoom factor of each scroll step, greater than 1 (default: cam_zoom_speed from the config)")
+	pos := flag.String("pos", "", "world position x,y the camera starts at (default: cam_pos from the config)")
+	bgColor := flag.String("bgcolor", "", "background color as #RRGGBB (default: background_color from the config)")
+	treeScale := flag.Float64("treescale", 0, "draw scale of planted trees, for sprites of another size (default: tree_scale from the config)")
+	noVSync := flag.Bool("novsync", false, "don't synchronize the frames with the monitor refresh rate (default: vsync from the config)")
+	fpsCap := flag.Float64("fpscap", 0, "cap the frame rate at this many FPS, 0 for no cap (default: fps_cap from the config)")
+	mapSize := flag.Int("mapsize", 0, "longest side in pixels of the forest map saved with F10 (default: map_size from the config)")
+	flag.Parse()
+
+	// Only use a time-based seed when -seed isn't given, so that -seed 0 is reproducible too.
+	// Benchmarks always use the fixed seed, so that their results can be compared
+	seeded := false
+	flag.Visit(func(f *flag.Flag) { seeded = seeded || f.Name == "seed" })
+	if !seeded && opts.benchmark == 0 {
+		opts.seed = time.Now().UnixNano()
+	}
+
+	if opts.frameSize <= 0 {
+		fmt.Fprintln(os.Stderr, "trees: -framesize must be positive")
+		os.Exit(2)
+	}
+	if opts.generate < 0 {
+		fmt.Fprintln(os.Stderr, "trees: -generate must not be negative")
+		os.Exit(2)
+	}
+	if opts.fontSize <= 0 {
+		fmt.Fprintln(os.Stderr, "trees: -fontsize must be positive")
+		os.Exit(2)
+	}
+	if opts.benchmark < 0 {
+		fmt.Fprintln(os.Stderr, "trees: -benchmark must not be negative")
+		os.Exit(2)
+	}
+
+	// Settings file
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trees: cannot load config: %v\n", err)
+		os.Exit(1)
+	}
+	opts.fileConfig = config
+	if *supersample != 0 {
+		if *supersample < 1 {
+			fmt.Fprintln(os.Stderr, "trees: -supersample must be at least 1")
+			os.Exit(2)
+		}
+		config.Supersample = *supersample
+	}
+	if *minZoom != 0 || *maxZoom != 0 {
+		if *minZoom != 0 {
+			config.MinZoom = *minZoom
+		}
+		if *maxZoom != 0 {
+			config.MaxZoom = *maxZoom
+		}
+		if config.MinZoom <= 0 || config.MinZoom >= config.MaxZoom {
+			fmt.Fprintf(os.Stderr, "trees: -minzoom must be positive and below the max zoom (%g to %g given)\n", config.MinZoom, config.MaxZoom)
+			os.Exit(2)
+		}
+		// The startup zoom of the config follows the new limits
+		config.CamZoom = math.Max(config.MinZoom, math.Min(config.MaxZoom, config.CamZoom))
+	}
+	if *zoomStep != 0 {
+		if *zoomStep <= 1 {
+			fmt.Fprintln(os.Stderr, "trees: -zoomstep must be greater than 1")
+			os.Exit(2)
+		}
+		config.CamZoomSpeed = *zoomStep
+	}
+	if *zoom != 0 {
+		if *zoom < config.MinZoom || *zoom > config.MaxZoom {
+			fmt.Fprintf(os.Stderr, "trees: -zoom must be between %g and %g\n", config.MinZoom, config.MaxZoom)
+			os.Exit(2)
+		}
+		config.CamZoom = *zoom
+		opts.camSet = true
+	}
+	if *pos != "" {
+		p, err := parsePos(*pos)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "trees: -pos: %v\n", err)
+			os.Exit(2)
+		}
+		config.CamPos = []float64{p.X, p.Y}
+		opts.camSet = true
+	}
+	if *treeScale != 0 {
+		if *treeScale < 0 {
+			fmt.Fprintln(os.Stderr, "trees: -treescale must be positive")
+			os.Exit(2)
+		}
+		config.TreeScale = *treeScale
+	}
+	if *noVSync {
+		config.VSync = false
+	}
+	// -fpscap 0 removes the cap of the config, so it only counts when given
+	fpsCapped := false
+	flag.Visit(func(f *flag.Flag) { fpsCapped = fpsCapped || f.Name == "fpscap" })
+	if fpsCapped {
+		if *fpsCap < 0 {
+			fmt.Fprintln(os.Stderr, "trees: -fpscap must not be negative")
+			os.Exit(2)
+		}
+		config.FPSCap = *fpsCap
+	}
+	if *mapSize != 0 {
+		if *mapSize < 1 || *mapSize > maxMapSize {
+			fmt.Fprintf(os.Stderr, "trees: -mapsize must be between 1 and %d\n", maxMapSize)
+			os.Exit(2)
+		}
+		config.MapSize = *mapSize
+	}
+	// A background color that can't be parsed falls back to the grass green
+	if *bgColor != "" {
+		config.BackgroundColor = *bgColor
+	}
+	if _, err := parseColor(config.BackgroundColor); err != nil {
+		fmt.Fprintf(os.Stderr, "trees: warning: background color: %v, using %s\n", err, defaultConfig().BackgroundColor)
+		config.BackgroundColor = defaultConfig().BackgroundColor
+	}
+	opts.config = config
+	opts.configPath = *configPath
+
+	// Keybindings, falling back to the default of any binding that can't be used
+	keys, warnings := loadKeybindings(*keysPath)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "trees: warning: %s\n", w)
+	}
+	opts.keys = keys
+
+	if opts.headless {
+		if err := runHeadless(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "trees: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the game loop defined in the run() function. pixelgl.Run takes a func(), so the
+	// error is kept to exit with once it returns
+	var runErr error
+	pixelgl.Run(func() { runErr = run(opts) })
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "trees: %v\n", runErr)
+		os.Exit(1)
+	}
+}
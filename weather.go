@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// Kinds of weather, cycled through in this order.
+const (
+	weatherClear = iota
+	weatherRain
+	weatherSnow
+)
+
+// weatherNames are the names of the kinds of weather, as set in the config.
+var weatherNames = []string{"clear", "rain", "snow"}
+
+const (
+	snowSlowdown = 0.15 // Snow falls at this fraction of the rain speed
+	snowDrift    = 30   // Side to side drift of the snowflakes, in screen pixels
+	rainSlant    = 0.15 // Horizontal distance the rain moves for every pixel it falls
+)
+
+// drop is a single raindrop or snowflake, in screen coordinates.
+type drop struct {
+	pos   pixel.Vec // Position in the window
+	speed float64   // Fraction of the fall speed, so drops don't all fall together
+	phase float64   // Offset of the drift of a snowflake, in radians
+}
+
+// weather is a layer of falling rain or snow drawn over the whole window, independent of the
+// world. Like the leaves, its randomness doesn't come from the planting random source.
+type weather struct {
+	kind  int
+	drops []drop
+	time  float64 // Seconds the weather ran, for the snow drift
+}
+
+// Update keeps density drops per 100x100 pixels of the window falling at up to speed pixels
+// per second for dt seconds, wrapping around to the top once they leave the window.
+func (w *weather) Update(dt, density, speed float64, window pixel.Rect) {
+	if w.kind == weatherClear {
+		w.drops = w.drops[:0]
+		return
+	}
+	n := int(window.Area() / (100 * 100) * density)
+	for len(w.drops) < n {
+		w.drops = append(w.drops, drop{
+			pos:   pixel.V(window.Min.X+rand.Float64()*window.W(), window.Min.Y+rand.Float64()*window.H()),
+			speed: 0.7 + rand.Float64()*0.3,
+			phase: rand.Float64() * 2 * math.Pi,
+		})
+	}
+	w.drops = w.drops[:n]
+
+	w.time += dt
+	fall := speed
+	if w.kind == weatherSnow {
+		fall *= snowSlowdown
+	}
+	for i := range w.drops {
+		d := &w.drops[i]
+		d.pos.Y -= fall * d.speed * dt
+		if w.kind == weatherRain {
+			d.pos.X -= fall * d.speed * dt * rainSlant
+		}
+		if d.pos.Y < window.Min.Y {
+			d.pos = pixel.V(window.Min.X+rand.Float64()*window.W(), window.Max.Y)
+		}
+		if d.pos.X < window.Min.X {
+			d.pos.X += window.W()
+		}
+	}
+}
+
+// Draw draws the drops into imd, in screen coordinates, with their color multiplied by tint
+// so that they darken at night along with the world.
+func (w *weather) Draw(imd *imdraw.IMDraw, tint pixel.RGBA) {
+	switch w.kind {
+	case weatherRain:
+		imd.Color = pixel.RGB(0.7, 0.75, 0.8).Mul(pixel.Alpha(0.5)).Mul(tint)
+		for _, d := range w.drops {
+			streak := pixel.V(rainSlant, 1).Scaled(12 * d.speed)
+			imd.Push(d.pos, d.pos.Add(streak))
+			imd.Line(1)
+		}
+	case weatherSnow:
+		imd.Color = pixel.RGB(1, 1, 1).Mul(pixel.Alpha(0.85)).Mul(tint)
+		for _, d := range w.drops {
+			imd.Push(d.pos.Add(pixel.V(math.Sin(w.time+d.phase)*snowDrift, 0)))
+			imd.Circle(1.5+d.speed, 0)
+		}
+	}
+}
+
+// weatherKind returns the kind of weather of a name from weatherNames, and whether it is one.
+func weatherKind(name string) (int, bool) {
+	for kind, n := range weatherNames {
+		if n == name {
+			return kind, true
+		}
+	}
+	return weatherClear, false
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// swayRotation returns the rotation bending a tree in the wind at time now (in seconds), to be
+// applied before the tree's own matrix. The tree rotates around the bottom of its frame, and its
+// position gives it a phase offset so that gusts travel through the forest instead of every tree
+// moving in sync.
+func swayRotation(t PlantedTree, frame pixel.Rect, now, amplitude, speed float64) pixel.Matrix {
+	phase := t.X*0.013 + t.Y*0.021
+	angle := amplitude * math.Sin(now*speed+phase)
+	return pixel.IM.Rotated(pixel.V(0, -frame.H()/2), angle)
+}